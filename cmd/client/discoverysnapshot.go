@@ -0,0 +1,98 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+//
+// Hand-written DiscoverySnapshot commands for the custom server routes.
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var discoverysnapshotReprocessCmd = &cobra.Command{
+	Use:   "reprocess [uid]",
+	Short: "Run a DiscoverySnapshot through reconciliation again",
+	Long: `Reset a DiscoverySnapshot to Pending and requeue it, e.g. after a mapping fix
+or a storage error. The server bumps status.attempts when processing starts.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		item, err := c.ReprocessDiscoverySnapshot(ctx, args[0])
+		if err != nil {
+			return fmt.Errorf("failed to reprocess DiscoverySnapshot: %w", err)
+		}
+
+		return printOutput(item)
+	},
+}
+
+var discoverysnapshotLatestCmd = &cobra.Command{
+	Use:   "latest",
+	Short: "Get the most recently collected DiscoverySnapshot for a BMC",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		source, _ := cmd.Flags().GetString("source")
+		phase, _ := cmd.Flags().GetString("phase")
+
+		c, err := getClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		item, err := c.GetLatestDiscoverySnapshot(ctx, source, phase)
+		if err != nil {
+			return fmt.Errorf("failed to get latest DiscoverySnapshot: %w", err)
+		}
+
+		return printOutput(item)
+	},
+}
+
+var discoverysnapshotDiffCmd = &cobra.Command{
+	Use:   "diff [from-uid] [to-uid]",
+	Short: "Show which devices changed between two DiscoverySnapshots",
+	Long: `Compare the devices in two DiscoverySnapshots. Devices are paired with the
+server's identity match keys and reported as added, removed or changed, with the
+fields that differ.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := getClient()
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		diff, err := c.DiffDiscoverySnapshots(ctx, args[0], args[1])
+		if err != nil {
+			return fmt.Errorf("failed to diff DiscoverySnapshots: %w", err)
+		}
+
+		return printOutput(diff)
+	},
+}
+
+func init() {
+	discoverysnapshotCmd.AddCommand(discoverysnapshotReprocessCmd)
+
+	discoverysnapshotLatestCmd.Flags().String("source", "", "BMC address the snapshot was collected from (required)")
+	discoverysnapshotLatestCmd.Flags().String("phase", "", "Only consider snapshots in this phase (e.g. Complete)")
+	discoverysnapshotLatestCmd.MarkFlagRequired("source")
+	discoverysnapshotCmd.AddCommand(discoverysnapshotLatestCmd)
+
+	discoverysnapshotCmd.AddCommand(discoverysnapshotDiffCmd)
+}
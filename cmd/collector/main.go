@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -10,17 +15,72 @@ import (
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "collector",
-	Short: "Gathers hardware inventory via Redfish and posts it to the OpenCHAMI API.",
-	Run:   executeGatherAndPost,
+	Use:           "collector",
+	Short:         "Gathers hardware inventory via Redfish and posts it to the OpenCHAMI API.",
+	RunE:          executeGatherAndPost,
+	SilenceUsage:  true,
+	SilenceErrors: true,
 }
 
-var bmcIP string
+var (
+	bmcIPs      []string
+	cidrs       []string
+	targetsFile string
+	workers     int
+	timeout     int
+	reqTimeout  int
+	retries     int
+	concurrency int
+	noExpand    bool
+	authMode    string
+	credsFile   string
+	credsCmd    string
+	caFile      string
+	knownHosts  string
+	insecure    bool
+	output      string
+	recordDir   string
+	replayDir   string
+	propMapFile string
+	removeAfter bool
+)
+
+var uploadCmd = &cobra.Command{
+	Use:   "upload <file-or-dir>...",
+	Short: "Posts snapshot files saved with --output to the API",
+	Long: `Posts snapshot files saved with --output to the API. Directories are
+searched for *.json files. Files whose snapshot is already on the server are
+skipped, so an interrupted upload can be run again.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: executeUpload,
+}
 
 func init() {
-	// Define the --ip flag for the BMC IP
-	rootCmd.Flags().StringVarP(&bmcIP, "ip", "i", "", "The IP address of the BMC to gather inventory from (required)")
-	rootCmd.MarkFlagRequired("ip")
+	// Targets may come from any mix of --ip, --cidr and --targets-file
+	rootCmd.Flags().StringArrayVarP(&bmcIPs, "ip", "i", nil, "IP address of a BMC to gather inventory from (repeatable)")
+	rootCmd.Flags().StringArrayVar(&cidrs, "cidr", nil, "CIDR range of BMCs to gather inventory from (repeatable)")
+	rootCmd.Flags().StringVar(&targetsFile, "targets-file", "", "YAML or JSON file listing BMC targets with optional per-target credentials")
+	rootCmd.Flags().StringVar(&credsFile, "credentials-file", "", "YAML or JSON file of BMC credentials matched by address, CIDR or glob")
+	rootCmd.Flags().StringVar(&credsCmd, "credentials-command", "", "Helper command that prints {\"username\", \"password\"} JSON for the BMC address given as its last argument")
+	rootCmd.Flags().StringVar(&caFile, "ca-file", "", "PEM bundle of CAs to verify BMC certificates against instead of the system roots")
+	rootCmd.Flags().StringVar(&knownHosts, "known-hosts", "", "Trust each BMC's certificate on first use and pin it in this file")
+	rootCmd.Flags().BoolVar(&insecure, "insecure", false, "Do not verify BMC certificates")
+	rootCmd.MarkFlagsMutuallyExclusive("ca-file", "known-hosts", "insecure")
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "Save snapshots to this directory (or file, for one BMC) instead of posting them")
+	rootCmd.Flags().StringVar(&recordDir, "record", "", "Save every Redfish response under this directory, one mockup per BMC, for --replay")
+	rootCmd.Flags().StringVar(&replayDir, "replay", "", "Read BMCs from recordings made with --record instead of the network")
+	rootCmd.MarkFlagsMutuallyExclusive("record", "replay")
+	rootCmd.Flags().StringVar(&propMapFile, "property-map", "", "YAML or JSON file of Redfish fields to copy into device properties, added to the defaults")
+	rootCmd.Flags().IntVar(&workers, "workers", collector.DefaultWorkers, "Number of BMCs to collect from concurrently")
+	rootCmd.Flags().IntVar(&timeout, "timeout", int(collector.DefaultTargetTimeout.Seconds()), "Per-BMC collection timeout in seconds")
+	rootCmd.Flags().IntVar(&reqTimeout, "request-timeout", int(collector.DefaultRequestTimeout.Seconds()), "Timeout for each Redfish request in seconds")
+	rootCmd.Flags().IntVar(&retries, "retries", collector.DefaultRetryPolicy.MaxAttempts-1, "Retries of a Redfish request after a 5xx, 429, timeout or connection reset")
+	rootCmd.Flags().IntVar(&concurrency, "bmc-concurrency", collector.DefaultMaxConcurrency, "Maximum concurrent requests to any one BMC")
+	rootCmd.Flags().StringVar(&authMode, "auth", collector.AuthSession, "Redfish authentication: \"session\" (X-Auth-Token) or \"basic\"")
+	rootCmd.Flags().BoolVar(&noExpand, "no-expand", false, "Do not use $expand, even if the BMC advertises support for it")
+
+	uploadCmd.Flags().BoolVar(&removeAfter, "remove", false, "Delete each file once its snapshot is on the server")
+	rootCmd.AddCommand(uploadCmd)
 }
 
 func main() {
@@ -31,15 +91,218 @@ func main() {
 }
 
 // executeGatherAndPost is the main function logic triggered by cobra.
-func executeGatherAndPost(cmd *cobra.Command, args []string) {
-	fmt.Printf("Starting inventory collection for BMC IP: %s\n", bmcIP)
+func executeGatherAndPost(cmd *cobra.Command, args []string) error {
+	if authMode != collector.AuthSession && authMode != collector.AuthBasic {
+		return fmt.Errorf("invalid --auth %q: must be %q or %q", authMode, collector.AuthSession, collector.AuthBasic)
+	}
+	targets, err := resolveTargets()
+	if err != nil {
+		return err
+	}
+	replayRoot := ""
+	if replayDir != "" {
+		// Every recording is replayed unless targets pick some by address
+		var recorded []collector.Target
+		if replayRoot, recorded, err = collector.ReplayTargets(replayDir); err != nil {
+			return err
+		}
+		if len(targets) == 0 {
+			targets = recorded
+		}
+	}
+	if len(targets) == 0 {
+		return errors.New("no targets given; use --ip, --cidr or --targets-file")
+	}
+	if len(targets) > 1 && output != "" && !isDirOutput(output) {
+		return fmt.Errorf("--output %s is a file; use a directory when collecting from more than one BMC", output)
+	}
+	if retries < 0 {
+		return fmt.Errorf("invalid --retries %d: must not be negative", retries)
+	}
+	retry := collector.DefaultRetryPolicy
+	retry.MaxAttempts = retries + 1
 
-	// Pass the IP captured by cobra to the new collector package
-	err := collector.CollectAndPost(bmcIP)
+	creds, err := credentialProvider()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Collection Failed: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	tlsOpts, err := tlsOptions()
+	if err != nil {
+		return err
+	}
+	var propMap collector.PropertyMap
+	if propMapFile != "" {
+		if propMap, err = collector.LoadPropertyMap(propMapFile); err != nil {
+			return err
+		}
 	}
+	fmt.Printf("Starting inventory collection for %d BMC(s) with %d worker(s)\n", len(targets), workers)
 
+	results, err := collector.CollectAll(context.Background(), targets, collector.Options{
+		Workers:     workers,
+		Timeout:     time.Duration(timeout) * time.Second,
+		Concurrency: concurrency,
+		NoExpand:    noExpand,
+		Auth:        authMode,
+		Credentials: creds,
+		TLS:         tlsOpts,
+
+		RequestTimeout: time.Duration(reqTimeout) * time.Second,
+		Retry:          retry,
+		Output:         output,
+		Record:         recordDir,
+		Replay:         replayRoot,
+		PropertyMap:    propMap,
+	})
+	if err != nil {
+		return fmt.Errorf("collection failed: %w", err)
+	}
+
+	if failed := printSummary(results); failed > 0 {
+		return fmt.Errorf("collection failed for %d of %d BMC(s)", failed, len(results))
+	}
+	if output != "" {
+		fmt.Printf("Snapshots saved; post them later with: collector upload %s\n", output)
+		return nil
+	}
 	fmt.Println("Inventory collection and posting completed successfully.")
-}
\ No newline at end of file
+	return nil
+}
+
+// executeUpload posts saved snapshot files.
+func executeUpload(cmd *cobra.Command, args []string) error {
+	files, err := collector.SnapshotFiles(args)
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return errors.New("no snapshot files found")
+	}
+	results, err := collector.Upload(context.Background(), files)
+	if err != nil {
+		return fmt.Errorf("upload failed: %w", err)
+	}
+
+	failed := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FILE\tSTATUS\tSNAPSHOT\tERROR")
+	for _, r := range results {
+		status, errMsg := "UPLOADED", ""
+		switch {
+		case r.Err != nil:
+			failed++
+			status, errMsg = "FAILED", r.Err.Error()
+		case r.Skipped:
+			status = "SKIPPED"
+		}
+		if r.Err == nil && removeAfter {
+			if err := os.Remove(r.File); err != nil {
+				errMsg = fmt.Sprintf("uploaded, but not removed: %v", err)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.File, status, r.SnapshotUID, errMsg)
+	}
+	w.Flush()
+	fmt.Printf("\n%d uploaded or already present, %d failed\n", len(results)-failed, failed)
+	if failed > 0 {
+		return fmt.Errorf("upload failed for %d of %d file(s)", failed, len(results))
+	}
+	return nil
+}
+
+// isDirOutput reports whether --output names a directory rather than a file.
+func isDirOutput(path string) bool {
+	if strings.HasSuffix(path, string(os.PathSeparator)) {
+		return true
+	}
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// resolveTargets merges the targets from every flag into one list.
+func resolveTargets() ([]collector.Target, error) {
+	var fromIP, fromCIDR, fromFile []collector.Target
+	for _, ip := range bmcIPs {
+		fromIP = append(fromIP, collector.Target{Address: ip})
+	}
+	for _, cidr := range cidrs {
+		hosts, err := collector.ExpandCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			fromCIDR = append(fromCIDR, collector.Target{Address: host})
+		}
+	}
+	if targetsFile != "" {
+		var err error
+		if fromFile, err = collector.LoadTargetsFile(targetsFile); err != nil {
+			return nil, err
+		}
+	}
+	return collector.MergeTargets(fromIP, fromCIDR, fromFile), nil
+}
+
+// credentialProvider chains the configured credential sources: the
+// credentials file, then the helper command, then the environment.
+func credentialProvider() (collector.CredentialProvider, error) {
+	var chain collector.ChainCredentials
+	if credsFile != "" {
+		file, err := collector.LoadCredentialsFile(credsFile)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, file)
+	}
+	if fields := strings.Fields(credsCmd); len(fields) > 0 {
+		chain = append(chain, collector.CommandCredentials{Command: fields[0], Args: fields[1:]})
+	}
+	return append(chain, collector.EnvCredentials{}), nil
+}
+
+// tlsOptions loads the certificate verification settings.
+func tlsOptions() (collector.TLSOptions, error) {
+	opts := collector.TLSOptions{Insecure: insecure}
+	if insecure {
+		fmt.Fprintln(os.Stderr, "Warning: --insecure set, BMC certificates will not be verified")
+	}
+	if caFile != "" {
+		pool, err := collector.LoadCABundle(caFile)
+		if err != nil {
+			return opts, err
+		}
+		opts.RootCAs = pool
+	}
+	if knownHosts != "" {
+		store, err := collector.LoadKnownHosts(knownHosts)
+		if err != nil {
+			return opts, err
+		}
+		opts.KnownHosts = store
+	}
+	return opts, nil
+}
+
+// printSummary prints one line per target and returns how many failed.
+func printSummary(results []collector.Result) int {
+	failed := 0
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TARGET\tSTATUS\tSNAPSHOT\tDEVICES\tWARNINGS\tDURATION\tERROR")
+	for _, r := range results {
+		status, errMsg := "OK", ""
+		if r.Err != nil {
+			failed++
+			status, errMsg = "FAILED", r.Err.Error()
+		}
+		snapshot := r.SnapshotUID
+		if r.File != "" {
+			snapshot = r.File
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+			r.Target, status, snapshot, r.Devices, r.Warnings, r.Duration.Round(time.Millisecond), errMsg)
+	}
+	w.Flush()
+	fmt.Printf("\n%d succeeded, %d failed\n", len(results)-failed, failed)
+	return failed
+}
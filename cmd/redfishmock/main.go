@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/user/inventory-api/pkg/redfishmock"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "redfishmock",
+	Short: "Serves a Redfish mockup so the collector can run without hardware.",
+	Long: `Serves a Redfish mockup so the collector can run without hardware.
+
+--mockup names a bundled mockup (` + strings.Join(redfishmock.Mockups(), ", ") + `)
+or a DMTF-style mockup directory of index.json files.`,
+	RunE:         serveMockup,
+	SilenceUsage: true,
+}
+
+var (
+	mockupName string
+	listenAddr string
+	plainHTTP  bool
+	username   string
+	password   string
+)
+
+func init() {
+	rootCmd.Flags().StringVarP(&mockupName, "mockup", "m", "single-node", "Bundled mockup name or mockup directory")
+	rootCmd.Flags().StringVarP(&listenAddr, "listen", "l", "127.0.0.1:0", "Address to listen on")
+	rootCmd.Flags().BoolVar(&plainHTTP, "http", false, "Serve plain HTTP instead of HTTPS")
+	rootCmd.Flags().StringVar(&username, "username", "", "Only accept this username (default: any)")
+	rootCmd.Flags().StringVar(&password, "password", "", "Password for --username")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func serveMockup(cmd *cobra.Command, args []string) error {
+	var mockup fs.FS
+	if info, err := os.Stat(mockupName); err == nil && info.IsDir() {
+		mockup = os.DirFS(mockupName)
+	} else if mockup, err = redfishmock.Mockup(mockupName); err != nil {
+		return err
+	}
+
+	server, err := redfishmock.NewUnstartedServer(mockup)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return err
+	}
+	server.Listener.Close()
+	server.Listener = listener
+	server.Username, server.Password = username, password
+	if plainHTTP {
+		server.Start()
+	} else {
+		server.StartTLS()
+	}
+	defer server.Close()
+
+	fmt.Printf("Serving mockup %s at %s/redfish/v1\n", mockupName, server.URL)
+	if plainHTTP {
+		fmt.Printf("Collect with: go run ./cmd/collector --ip %s\n", server.URL)
+	} else {
+		fmt.Printf("Collect with: go run ./cmd/collector --ip %s --insecure\n", server.Address())
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	<-stop
+	return nil
+}
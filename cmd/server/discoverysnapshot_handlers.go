@@ -0,0 +1,148 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+//
+// Custom DiscoverySnapshot queries and actions. See routes.go for registration.
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/fabrica/pkg/reconcile"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/reconcilers"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+)
+
+// ReprocessDiscoverySnapshot puts a finished snapshot back in the queue.
+// Complete and Error snapshots are reset to Pending, with their attempts and
+// results cleared, and handed to the controller.
+func ReprocessDiscoverySnapshot(w http.ResponseWriter, r *http.Request) {
+	uid := chi.URLParam(r, "uid")
+	if uid == "" {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("DiscoverySnapshot UID is required"))
+		return
+	}
+	if globalController == nil {
+		respondError(w, http.StatusServiceUnavailable, fmt.Errorf("reconciliation controller is not running"))
+		return
+	}
+
+	res, err := storage.LoadDiscoverySnapshot(r.Context(), uid)
+	if err != nil {
+		respondError(w, http.StatusNotFound, fmt.Errorf("DiscoverySnapshot not found: %w", err))
+		return
+	}
+	if res.Status.RawDataStrippedAt != nil {
+		respondError(w, http.StatusConflict, fmt.Errorf("DiscoverySnapshot %s has no raw data left to reprocess (removed by the retention policy)", uid))
+		return
+	}
+	switch res.Status.CurrentPhase() {
+	case discoverysnapshot.PhasePending:
+		// Already waiting; just make sure it is queued.
+	case discoverysnapshot.PhaseProcessing:
+		// Only the reconciler moves a snapshot out of Processing.
+		respondError(w, http.StatusConflict, fmt.Errorf("DiscoverySnapshot %s is being processed", uid))
+		return
+	default:
+		if err := res.Status.SetPhase(discoverysnapshot.PhasePending, "Reprocessing requested."); err != nil {
+			respondError(w, http.StatusConflict, fmt.Errorf("cannot reprocess DiscoverySnapshot %s: %w", uid, err))
+			return
+		}
+		res.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonReprocessRequested, "Waiting for the reconciler.")
+		// A reprocess starts over: a fresh retry budget, and no results left
+		// from the previous run to be mistaken for this one's.
+		res.Status.Attempts = 0
+		res.Status.Summary = nil
+		res.Status.Results = nil
+		res.Status.AmbiguousMatches = nil
+		res.Touch()
+		if err := storage.SaveDiscoverySnapshot(r.Context(), res); err != nil {
+			respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to save DiscoverySnapshot: %w", err))
+			return
+		}
+	}
+
+	if err := globalController.Enqueue(reconcile.ReconcileRequest{
+		ResourceKind: "DiscoverySnapshot",
+		ResourceUID:  uid,
+		Reason:       "Reprocess requested",
+	}); err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to requeue DiscoverySnapshot: %w", err))
+		return
+	}
+
+	respondJSON(w, http.StatusAccepted, res)
+}
+
+// GetLatestDiscoverySnapshot returns the most recently collected snapshot for
+// ?source=<bmc>. An optional ?phase= (e.g. Complete) restricts the search to
+// snapshots in that phase. Collection time comes from the snapshot provenance,
+// falling back to its creation time.
+func GetLatestDiscoverySnapshot(w http.ResponseWriter, r *http.Request) {
+	source := r.URL.Query().Get("source")
+	if source == "" {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("the source query parameter is required"))
+		return
+	}
+	phase := r.URL.Query().Get("phase")
+
+	snapshots, err := storage.LoadAllDiscoverySnapshots(r.Context())
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, fmt.Errorf("failed to load discoverysnapshots: %w", err))
+		return
+	}
+	var latest *discoverysnapshot.DiscoverySnapshot
+	for _, s := range snapshots {
+		if s.SourceAddress() != source || (phase != "" && s.Status.CurrentPhase() != phase) {
+			continue
+		}
+		if latest == nil || s.CollectedAt().After(latest.CollectedAt()) {
+			latest = s
+		}
+	}
+	if latest == nil {
+		respondError(w, http.StatusNotFound, fmt.Errorf("no DiscoverySnapshot found for source %q", source))
+		return
+	}
+	respondJSON(w, http.StatusOK, latest)
+}
+
+// DiffDiscoverySnapshots compares the devices in snapshot {uid} (the older one)
+// with those in snapshot {other}, pairing them with the server's identity match keys.
+func DiffDiscoverySnapshots(w http.ResponseWriter, r *http.Request) {
+	from, ok := loadDiscoverySnapshotParam(w, r, "uid")
+	if !ok {
+		return
+	}
+	to, ok := loadDiscoverySnapshotParam(w, r, "other")
+	if !ok {
+		return
+	}
+
+	diff, err := reconcilers.DiffSnapshots(from, to, globalMatchKeys)
+	if err != nil {
+		respondError(w, http.StatusUnprocessableEntity, fmt.Errorf("failed to diff DiscoverySnapshots: %w", err))
+		return
+	}
+	respondJSON(w, http.StatusOK, diff)
+}
+
+// loadDiscoverySnapshotParam loads the snapshot named by a URL parameter,
+// responding with an error and returning false if it cannot.
+func loadDiscoverySnapshotParam(w http.ResponseWriter, r *http.Request, param string) (*discoverysnapshot.DiscoverySnapshot, bool) {
+	uid := chi.URLParam(r, param)
+	if uid == "" {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("DiscoverySnapshot UID is required"))
+		return nil, false
+	}
+	res, err := storage.LoadDiscoverySnapshot(r.Context(), uid)
+	if err != nil {
+		respondError(w, http.StatusNotFound, fmt.Errorf("DiscoverySnapshot %s not found: %w", uid, err))
+		return nil, false
+	}
+	return res, true
+}
@@ -135,6 +135,10 @@ func CreateDiscoverySnapshot(w http.ResponseWriter, r *http.Request) {
     }
 
     // Set initial status
+    // <<< FIX: Start the lifecycle explicitly instead of leaving the phase empty
+    discoverySnapshot.Status.Phase = discoverysnapshot.PhasePending
+    discoverySnapshot.Status.Message = "Snapshot queued for processing."
+    discoverySnapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonQueued, "Waiting for the reconciler.")
 
     // Save (Layer 1: Ent validation happens automatically if using Ent storage)
     if err := storage.SaveDiscoverySnapshot(r.Context(), discoverySnapshot); err != nil {
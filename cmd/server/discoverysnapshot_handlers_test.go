@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/openchami/fabrica/pkg/reconcile"
+	fabricaStorage "github.com/openchami/fabrica/pkg/storage"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// newTestRouter serves the custom routes over an empty file backend.
+// storage.Init is global, so tests must not run in parallel.
+func newTestRouter(t *testing.T) http.Handler {
+	t.Helper()
+	backend, err := fabricaStorage.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage.Init(backend)
+	r := chi.NewRouter()
+	RegisterCustomRoutes(r)
+	return r
+}
+
+// testSnapshot describes a snapshot to store.
+type testSnapshot struct {
+	uid         string
+	source      string
+	phase       string
+	createdAt   time.Time
+	completedAt time.Time // Zero means no provenance
+	data        string    // Device list; defaults to []
+}
+
+func saveSnapshot(t *testing.T, s testSnapshot) {
+	t.Helper()
+	data := s.data
+	if data == "" {
+		data = "[]"
+	}
+	snapshot := &discoverysnapshot.DiscoverySnapshot{
+		Spec: discoverysnapshot.DiscoverySnapshotSpec{Source: s.source, RawData: json.RawMessage(data)},
+	}
+	snapshot.Metadata.Initialize("snapshot-"+s.uid, s.uid)
+	snapshot.Metadata.CreatedAt = s.createdAt
+	snapshot.Status.Phase = s.phase
+	if !s.completedAt.IsZero() {
+		snapshot.Status.Provenance = &snapshotformat.Provenance{Source: s.source, CompletedAt: s.completedAt}
+	}
+	if err := storage.SaveDiscoverySnapshot(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// get serves a request and decodes a successful JSON response into out.
+func get(t *testing.T, h http.Handler, target string, out interface{}) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if rec.Code == http.StatusOK && out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decoding %s: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestGetLatestDiscoverySnapshot(t *testing.T) {
+	base := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	h := newTestRouter(t)
+	for _, s := range []testSnapshot{
+		// Created last, but collected first
+		{uid: "ds-a", source: "10.0.0.1", phase: "Complete", createdAt: base.Add(3 * time.Hour), completedAt: base},
+		{uid: "ds-b", source: "10.0.0.1", phase: "Complete", createdAt: base, completedAt: base.Add(time.Hour)},
+		// No provenance, so its creation time counts
+		{uid: "ds-c", source: "10.0.0.1", phase: "Error", createdAt: base.Add(2 * time.Hour)},
+		{uid: "ds-d", source: "10.0.0.2", phase: "Complete", createdAt: base.Add(5 * time.Hour)},
+	} {
+		saveSnapshot(t, s)
+	}
+
+	tests := []struct {
+		query string
+		code  int
+		uid   string
+	}{
+		{"source=10.0.0.1", http.StatusOK, "ds-c"},
+		{"source=10.0.0.1&phase=Complete", http.StatusOK, "ds-b"},
+		{"source=10.0.0.1&phase=Processing", http.StatusNotFound, ""},
+		{"source=10.0.0.2", http.StatusOK, "ds-d"},
+		{"source=10.0.0.9", http.StatusNotFound, ""},
+		{"", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		var got discoverysnapshot.DiscoverySnapshot
+		code := get(t, h, "/discoverysnapshots/latest?"+tt.query, &got)
+		if code != tt.code || got.GetUID() != tt.uid {
+			t.Errorf("latest?%s = %d %q, want %d %q", tt.query, code, got.GetUID(), tt.code, tt.uid)
+		}
+	}
+}
+
+func TestDiffDiscoverySnapshots(t *testing.T) {
+	h := newTestRouter(t)
+	saveSnapshot(t, testSnapshot{uid: "ds-a", source: "10.0.0.1",
+		data: `[{"deviceType": "Node", "manufacturer": "Contoso", "serialNumber": "SN1", "properties": {"redfish_uri": "/Systems/1"}}]`})
+	saveSnapshot(t, testSnapshot{uid: "ds-b", source: "10.0.0.1",
+		data: `[{"deviceType": "Node", "manufacturer": "Contoso", "serialNumber": "SN1", "properties": {"redfish_uri": "/Systems/1", "bios_version": "2.1"}}]`})
+
+	var diff discoverysnapshot.SnapshotDiff
+	if code := get(t, h, "/discoverysnapshots/ds-a/diff/ds-b", &diff); code != http.StatusOK {
+		t.Fatalf("diff = %d, want 200", code)
+	}
+	if diff.From != "ds-a" || diff.To != "ds-b" || len(diff.Changed) != 1 || diff.Changed[0].Changes[0].Field != "properties.bios_version" {
+		t.Errorf("diff = %+v, want the node's bios_version changed", diff)
+	}
+
+	if code := get(t, h, "/discoverysnapshots/ds-a/diff/ds-missing", nil); code != http.StatusNotFound {
+		t.Errorf("diff against a missing snapshot = %d, want 404", code)
+	}
+}
+
+func TestReprocessDiscoverySnapshot(t *testing.T) {
+	h := newTestRouter(t)
+	defer func(c *reconcile.Controller) { globalController = c }(globalController)
+	globalController = reconcile.NewController(nil, nil)
+
+	saveSnapshot(t, testSnapshot{uid: "ds-done", phase: discoverysnapshot.PhaseComplete})
+	saveSnapshot(t, testSnapshot{uid: "ds-busy", phase: discoverysnapshot.PhaseProcessing})
+	snapshot, err := storage.LoadDiscoverySnapshot(context.Background(), "ds-done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot.Status.Attempts = 3
+	snapshot.Status.Summary = &discoverysnapshot.IngestSummary{Created: 2, Failed: 1}
+	snapshot.Status.Results = []discoverysnapshot.DeviceResult{{UID: "dev-1", Action: "Created"}}
+	snapshot.Status.AmbiguousMatches = []discoverysnapshot.AmbiguousMatch{{Device: "node", MatchKey: "serial_manufacturer"}}
+	if err := storage.SaveDiscoverySnapshot(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	post := func(uid string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/discoverysnapshots/"+uid+"/reprocess", nil))
+		return rec.Code
+	}
+	if code := post("ds-busy"); code != http.StatusConflict {
+		t.Errorf("reprocessing a snapshot being processed = %d, want %d", code, http.StatusConflict)
+	}
+	if code := post("ds-missing"); code != http.StatusNotFound {
+		t.Errorf("reprocessing a missing snapshot = %d, want %d", code, http.StatusNotFound)
+	}
+	if code := post("ds-done"); code != http.StatusAccepted {
+		t.Fatalf("reprocess = %d, want %d", code, http.StatusAccepted)
+	}
+
+	snapshot, err = storage.LoadDiscoverySnapshot(context.Background(), "ds-done")
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := snapshot.Status
+	if status.CurrentPhase() != discoverysnapshot.PhasePending {
+		t.Errorf("phase = %s, want %s", status.CurrentPhase(), discoverysnapshot.PhasePending)
+	}
+	if status.Attempts != 0 || status.Summary != nil || status.Results != nil || status.AmbiguousMatches != nil {
+		t.Errorf("reprocess kept the previous run's attempts or results: %+v", status)
+	}
+}
@@ -20,24 +20,22 @@ import (
 	// Import the base storage interface
 	fabrica_storage "github.com/openchami/fabrica/pkg/storage"
 	"github.com/user/inventory-api/pkg/reconcilers"
+	"github.com/user/inventory-api/pkg/retention"
 
 	// Import the GENERATED storage implementation
 	internal_storage "github.com/user/inventory-api/internal/storage"
 
 	// Import the 'internal/middleware' package for events
 	internal_events "github.com/user/inventory-api/internal/middleware"
-
-	// --- DEBUGGING IMPORTS ---
-	"github.com/openchami/fabrica/pkg/resource"
-	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
-	// --- END DEBUGGING IMPORTS ---
 )
 
 // --- Global variables for handlers ---
 var (
 	// Use the aliased interface type
-	globalStorage fabrica_storage.StorageBackend
-	globalEventBus events.EventBus
+	globalStorage    fabrica_storage.StorageBackend
+	globalEventBus   events.EventBus
+	globalController *reconcile.Controller
+	globalMatchKeys  []reconcilers.MatchKey
 )
 
 // SetStorageBackend sets the global storage backend
@@ -49,6 +47,16 @@ func SetStorageBackend(s fabrica_storage.StorageBackend) {
 func SetEventBus(eb events.EventBus) {
 	globalEventBus = eb
 }
+
+// SetController sets the global reconciliation controller
+func SetController(c *reconcile.Controller) {
+	globalController = c
+}
+
+// SetMatchKeys sets the device identity rules used outside the reconciler (e.g. snapshot diffs)
+func SetMatchKeys(keys []reconcilers.MatchKey) {
+	globalMatchKeys = keys
+}
 // --- End global variables ---
 
 
@@ -64,6 +72,24 @@ type Config struct {
 	// Storage Configuration
 	DataDir string `mapstructure:"data_dir"`
 
+	// Reconciliation Configuration
+	// IdentityMatchKeys is the ordered list of rules used to match snapshot entries to existing Devices.
+	IdentityMatchKeys []string `mapstructure:"identity_match_keys"`
+	// SnapshotMaxAttempts caps automatic retries of snapshots that hit transient errors.
+	SnapshotMaxAttempts int `mapstructure:"snapshot_max_attempts"`
+	// SnapshotRetryDelay is the first retry backoff in seconds; it doubles per attempt.
+	SnapshotRetryDelay int `mapstructure:"snapshot_retry_delay"`
+
+	// Snapshot Retention Configuration
+	// SnapshotKeepLast is how many of the newest snapshots are kept per source.
+	SnapshotKeepLast int `mapstructure:"snapshot_keep_last"`
+	// SnapshotKeepDays keeps every snapshot collected within this many days.
+	SnapshotKeepDays int `mapstructure:"snapshot_keep_days"`
+	// SnapshotStripRawData strips rawData from expired Complete snapshots instead of deleting them.
+	SnapshotStripRawData bool `mapstructure:"snapshot_strip_raw_data"`
+	// SnapshotGCInterval is how often, in seconds, the server sweeps snapshots; 0 disables the sweeper.
+	SnapshotGCInterval int `mapstructure:"snapshot_gc_interval"`
+
 	// Feature Flags
 	Debug bool `mapstructure:"debug"`
 }
@@ -77,7 +103,18 @@ func DefaultConfig() *Config {
 		WriteTimeout: 15,
 		IdleTimeout:  60,
 		DataDir:      "./data",
-		Debug:        false,
+		IdentityMatchKeys: []string{
+			string(reconcilers.MatchSerialManufacturer),
+			string(reconcilers.MatchRedfishURI),
+			string(reconcilers.MatchHPCMUUID),
+			string(reconcilers.MatchDiscoveryRef),
+		},
+		SnapshotMaxAttempts: reconcilers.DefaultMaxAttempts,
+		SnapshotRetryDelay:  int(reconcilers.DefaultRetryBaseDelay / time.Second),
+		SnapshotKeepLast:    10,
+		SnapshotKeepDays:    30,
+		SnapshotGCInterval:  3600,
+		Debug:               false,
 	}
 }
 
@@ -122,12 +159,34 @@ func init() {
 
 	serveCmd.Flags().String("data-dir", "./data", "Directory for file storage")
 
+	serveCmd.Flags().StringSlice("identity-match-keys", DefaultConfig().IdentityMatchKeys, "Ordered device identity rules: serial_manufacturer, redfish_uri, hpcm_uuid, discovery_ref")
+
+	serveCmd.Flags().Int("snapshot-max-attempts", DefaultConfig().SnapshotMaxAttempts, "Attempts before a snapshot with transient errors is marked Error")
+	serveCmd.Flags().Int("snapshot-retry-delay", DefaultConfig().SnapshotRetryDelay, "First retry backoff in seconds for snapshots with transient errors")
+
 	// Bind flags to viper
 	viper.BindPFlags(serveCmd.Flags())
+	viper.BindPFlag("identity_match_keys", serveCmd.Flags().Lookup("identity-match-keys"))
+	viper.BindPFlag("snapshot_max_attempts", serveCmd.Flags().Lookup("snapshot-max-attempts"))
+	viper.BindPFlag("snapshot_retry_delay", serveCmd.Flags().Lookup("snapshot-retry-delay"))
 	viper.BindPFlags(rootCmd.PersistentFlags())
 
+	// Retention flags are shared by serve (the sweeper) and gc
+	rootCmd.PersistentFlags().Int("snapshot-keep-last", DefaultConfig().SnapshotKeepLast, "Newest snapshots kept per source")
+	rootCmd.PersistentFlags().Int("snapshot-keep-days", DefaultConfig().SnapshotKeepDays, "Keep every snapshot collected within this many days")
+	rootCmd.PersistentFlags().Bool("snapshot-strip-raw-data", false, "Strip rawData from expired Complete snapshots instead of deleting them")
+	serveCmd.Flags().Int("snapshot-gc-interval", DefaultConfig().SnapshotGCInterval, "Seconds between snapshot retention sweeps (0 disables)")
+	viper.BindPFlag("snapshot_keep_last", rootCmd.PersistentFlags().Lookup("snapshot-keep-last"))
+	viper.BindPFlag("snapshot_keep_days", rootCmd.PersistentFlags().Lookup("snapshot-keep-days"))
+	viper.BindPFlag("snapshot_strip_raw_data", rootCmd.PersistentFlags().Lookup("snapshot-strip-raw-data"))
+	viper.BindPFlag("snapshot_gc_interval", serveCmd.Flags().Lookup("snapshot-gc-interval"))
+
+	gcCmd.Flags().Bool("dry-run", false, "List the snapshots that would be deleted or stripped without changing anything")
+	gcCmd.Flags().String("data-dir", "./data", "Directory for file storage")
+
 	// Add subcommands
 	rootCmd.AddCommand(serveCmd)
+	rootCmd.AddCommand(gcCmd)
 	rootCmd.AddCommand(versionCmd)
 }
 
@@ -199,17 +258,28 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// --- 3. Initialize Reconciliation Controller ---
 	log.Println("Initializing reconciliation controller...")
 	controller := reconcile.NewController(eventBus, storageBackend)
+	SetController(controller)
 
 	
 	// --- 4. Register Reconcilers ---
 	log.Println("Registering reconcilers...")
+	matchKeys, err := reconcilers.ParseMatchKeys(config.IdentityMatchKeys)
+	if err != nil {
+		return fmt.Errorf("invalid identity_match_keys: %w", err)
+	}
 	snapshotReconciler := &reconcilers.DiscoverySnapshotReconciler{
 		BaseReconciler: reconcile.BaseReconciler{
 			EventBus: eventBus,
-			Logger:   reconcile.NewDefaultLogger(), // Simple logger
+			Client:   internal_storage.NewStorageClient(), // Status writes reload the snapshot so spec edits survive
+			Logger:   reconcile.NewDefaultLogger(),        // Simple logger
 		},
-		Storage: storageBackend,
+		Storage:        storageBackend,
+		MatchKeys:      matchKeys,
+		MaxAttempts:    config.SnapshotMaxAttempts,
+		RetryBaseDelay: time.Duration(config.SnapshotRetryDelay) * time.Second,
 	}
+	log.Printf("Device identity match keys: %v", matchKeys)
+	SetMatchKeys(matchKeys)
 	controller.RegisterReconciler(snapshotReconciler)
 	log.Printf("Registered reconciler for %s", snapshotReconciler.GetResourceKind())
 
@@ -225,6 +295,17 @@ func runServer(cmd *cobra.Command, args []string) error {
 	}()
 
 
+	// --- 5b. Start Snapshot Retention Sweeper ---
+	if config.SnapshotGCInterval > 0 {
+		policy := retentionPolicy()
+		if err := policy.Validate(); err != nil {
+			controllerCancel()
+			return fmt.Errorf("invalid snapshot retention policy: %w", err)
+		}
+		go runSnapshotSweeper(controllerCtx, policy, time.Duration(config.SnapshotGCInterval)*time.Second)
+	}
+
+
 	// --- 6. Setup Router ---
 	r := chi.NewRouter()
 	r.Use(middleware.Logger)
@@ -238,6 +319,7 @@ func runServer(cmd *cobra.Command, args []string) error {
 	// We removed the empty event middleware
 	
 	RegisterGeneratedRoutes(r) 
+	RegisterCustomRoutes(r)
 	r.Get("/health", healthHandler)
 
 	
@@ -259,42 +341,6 @@ func runServer(cmd *cobra.Command, args []string) error {
 		}
 	}()
 
-	
-	// --- DEBUGGING: MANUALLY PUBLISH A DUMMY EVENT ---
-	go func() {
-		log.Println("[DEBUG] --- Waiting 5 seconds before publishing dummy event ---")
-		time.Sleep(5 * time.Second)
-		log.Println("[DEBUG] --- Publishing dummy DiscoverySnapshot event ---")
-		
-		// 1. Create a dummy snapshot
-		uid, _ := resource.GenerateUIDForResource("DiscoverySnapshot")
-		dummySnapshot := &discoverysnapshot.DiscoverySnapshot{
-			Resource: resource.Resource{
-				APIVersion: "v1",
-				Kind:       "DiscoverySnapshot",
-			},
-			Spec: discoverysnapshot.DiscoverySnapshotSpec{
-				RawData: []byte(`{"test": "true"}`),
-			},
-		}
-		dummySnapshot.Metadata.Initialize("dummy-snapshot", uid)
-
-		// 2. Save it to storage (so the reconciler can find it)
-		if err := internal_storage.SaveDiscoverySnapshot(context.Background(), dummySnapshot); err != nil {
-			log.Printf("[DEBUG] FAILED to save dummy snapshot: %v", err)
-			return
-		}
-		log.Printf("[DEBUG] Dummy snapshot %s saved to storage.", uid)
-
-		// 3. Publish the event using the *exact* function the handler *should* be calling
-		if err := internal_events.PublishResourceEvent(context.Background(), "created", "DiscoverySnapshot", uid, dummySnapshot); err != nil {
-			log.Printf("[DEBUG] FAILED to publish dummy event: %v", err)
-			return
-		}
-		log.Println("[DEBUG] --- Dummy event published successfully ---")
-	}()
-	// --- END DEBUGGING ---
-
 
 	// --- 8. Wait for Interrupt (Graceful Shutdown) ---
 	quit := make(chan os.Signal, 1)
@@ -328,6 +374,70 @@ func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{"status":"healthy","service":"inventory-api"}`))
 }
 
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Apply the snapshot retention policy once",
+	Long: `Delete (or strip the rawData of) DiscoverySnapshots that fall outside the
+retention policy, the same way the server's background sweeper does.
+Use --dry-run to see what would change.`,
+	SilenceUsage: true,
+	RunE:         runGC,
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	dataDir := config.DataDir
+	if cmd.Flags().Changed("data-dir") {
+		dataDir, _ = cmd.Flags().GetString("data-dir")
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+	if err := internal_storage.InitFileBackend(dataDir); err != nil {
+		return fmt.Errorf("failed to initialize file storage: %w", err)
+	}
+
+	decisions, err := retention.Sweep(context.Background(), retentionPolicy(), time.Now(), dryRun)
+	for _, d := range decisions {
+		fmt.Println(d)
+	}
+	verb := "Applied"
+	if dryRun {
+		verb = "Would apply"
+	}
+	fmt.Printf("%s %d retention actions in %s\n", verb, len(decisions), dataDir)
+	return err
+}
+
+// retentionPolicy builds the snapshot retention policy from the configuration.
+func retentionPolicy() retention.Policy {
+	return retention.Policy{
+		KeepLast:     config.SnapshotKeepLast,
+		KeepFor:      time.Duration(config.SnapshotKeepDays) * 24 * time.Hour,
+		StripRawData: config.SnapshotStripRawData,
+	}
+}
+
+// runSnapshotSweeper applies the retention policy every interval until ctx is done.
+func runSnapshotSweeper(ctx context.Context, policy retention.Policy, interval time.Duration) {
+	log.Printf("Snapshot retention sweeper running every %s (keep last %d per source, keep %s)", interval, policy.KeepLast, policy.KeepFor)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		decisions, err := retention.Sweep(ctx, policy, time.Now(), false)
+		for _, d := range decisions {
+			log.Printf("Snapshot retention: %s", d)
+		}
+		if err != nil {
+			log.Printf("Snapshot retention sweep failed: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Print the version number",
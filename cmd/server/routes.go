@@ -0,0 +1,22 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+//
+// Hand-written routes that are not part of the generated CRUD surface.
+// RegisterCustomRoutes is called after RegisterGeneratedRoutes in main.go.
+package main
+
+import (
+	"github.com/go-chi/chi/v5"
+)
+
+// RegisterCustomRoutes registers action endpoints alongside the generated routes.
+func RegisterCustomRoutes(r chi.Router) {
+	// DiscoverySnapshot queries. The static "latest" segment takes precedence
+	// over the generated /discoverysnapshots/{uid} route.
+	r.Get("/discoverysnapshots/latest", GetLatestDiscoverySnapshot)
+	r.Get("/discoverysnapshots/{uid}/diff/{other}", DiffDiscoverySnapshots)
+
+	// DiscoverySnapshot actions
+	r.Post("/discoverysnapshots/{uid}/reprocess", ReprocessDiscoverySnapshot)
+}
@@ -61,7 +61,10 @@ func InitializeEventBus() error {
 // initMemoryBus creates an in-memory event bus
 func initMemoryBus() (events.EventBus, error) {
     bus := events.NewInMemoryEventBus(100, 5)
-    
+
+    // <<< FIX: The in-memory bus only dispatches once its workers are started
+    bus.Start()
+
     // <<< FIX: Assign the bus to this package's GlobalEventBus variable
     GlobalEventBus = bus 
     // <<< END FIX
@@ -120,7 +123,22 @@ func PublishResourceEvent(ctx context.Context, action, resourceType, resourceID
         "timestamp": time.Now().UTC(),
     }
 
-    return PublishEvent(ctx, eventType, source, eventData)
+    if !EventsEnabled || GlobalEventBus == nil {
+        return fmt.Errorf("events are not enabled or event bus is nil")
+    }
+
+    evt, err := events.NewEvent(eventType, source, eventData)
+    if err != nil {
+        return fmt.Errorf("failed to create event: %w", err)
+    }
+
+    // <<< FIX: The reconciliation controller routes events by these extensions
+    evt.SetExtension("resourcekind", resourceType)
+    evt.SetExtension("resourceuid", resourceID)
+    evt.SetExtension("action", action)
+    // <<< END FIX
+
+    return GlobalEventBus.Publish(ctx, *evt)
 }
 
 // SubscribeToEvents subscribes to events matching the given type pattern
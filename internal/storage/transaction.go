@@ -0,0 +1,126 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	fabricaStorage "github.com/openchami/fabrica/pkg/storage"
+)
+
+// Tx stages writes against a storage backend and applies them together.
+//
+// Fabrica backends have no native transactions, so Commit applies the staged
+// writes one at a time and, if any fails, restores what it already changed:
+// resources that existed get their previous data back, new ones are deleted.
+// Writes made by others between Commit's reads and its rollback are not
+// protected; callers serialize their own work (the reconciler processes one
+// snapshot at a time).
+//
+// Example:
+//
+//	tx := storage.Begin(backend)
+//	tx.Save(ctx, "Device", uid, data)
+//	if err := tx.Commit(ctx); err != nil {
+//	    // nothing was changed
+//	}
+type Tx struct {
+	backend fabricaStorage.StorageBackend
+	writes  []stagedWrite
+	done    bool
+}
+
+type stagedWrite struct {
+	kind string
+	uid  string
+	data []byte
+}
+
+// appliedWrite remembers what a committed write replaced, for rollback.
+type appliedWrite struct {
+	stagedWrite
+	previous []byte // nil if the resource did not exist
+}
+
+// Begin starts a transaction against backend.
+func Begin(backend fabricaStorage.StorageBackend) *Tx {
+	return &Tx{backend: backend}
+}
+
+// Save stages a write. Nothing reaches the backend until Commit.
+// A later Save of the same resource replaces the earlier one.
+func (tx *Tx) Save(ctx context.Context, kind, uid string, data []byte) error {
+	if tx.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	for i := range tx.writes {
+		if tx.writes[i].kind == kind && tx.writes[i].uid == uid {
+			tx.writes[i].data = data
+			return nil
+		}
+	}
+	tx.writes = append(tx.writes, stagedWrite{kind: kind, uid: uid, data: data})
+	return nil
+}
+
+// Len returns the number of staged writes.
+func (tx *Tx) Len() int {
+	return len(tx.writes)
+}
+
+// Commit applies every staged write, or none of them. If a write fails the
+// writes already applied are rolled back and the original error is returned,
+// joined with any error hit while rolling back.
+func (tx *Tx) Commit(ctx context.Context) error {
+	if tx.done {
+		return fmt.Errorf("transaction already finished")
+	}
+	tx.done = true
+
+	applied := make([]appliedWrite, 0, len(tx.writes))
+	for _, w := range tx.writes {
+		previous, err := tx.backend.Load(ctx, w.kind, w.uid)
+		if err != nil && !errors.Is(err, fabricaStorage.ErrNotFound) {
+			return tx.rollback(ctx, applied, fmt.Errorf("failed to read %s %s before writing: %w", w.kind, w.uid, err))
+		}
+		if err := tx.backend.Save(ctx, w.kind, w.uid, w.data); err != nil {
+			return tx.rollback(ctx, applied, fmt.Errorf("failed to save %s %s: %w", w.kind, w.uid, err))
+		}
+		applied = append(applied, appliedWrite{stagedWrite: w, previous: previous})
+	}
+	return nil
+}
+
+// Rollback discards the staged writes without touching the backend.
+func (tx *Tx) Rollback() {
+	tx.done = true
+	tx.writes = nil
+}
+
+// rollback undoes applied writes in reverse order.
+func (tx *Tx) rollback(ctx context.Context, applied []appliedWrite, cause error) error {
+	// Keep undoing even if the caller's context is already cancelled.
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	for i := len(applied) - 1; i >= 0; i-- {
+		w := applied[i]
+		var err error
+		if w.previous == nil {
+			err = tx.backend.Delete(ctx, w.kind, w.uid)
+		} else {
+			err = tx.backend.Save(ctx, w.kind, w.uid, w.previous)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to roll back %s %s: %w", w.kind, w.uid, err))
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(append([]error{cause}, errs...)...)
+	}
+	return cause
+}
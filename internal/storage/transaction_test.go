@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	fabricaStorage "github.com/openchami/fabrica/pkg/storage"
+)
+
+// failingBackend fails every Save of one resource.
+type failingBackend struct {
+	fabricaStorage.StorageBackend
+	failUID string
+	saves   int
+}
+
+func (b *failingBackend) Save(ctx context.Context, kind, uid string, data json.RawMessage) error {
+	b.saves++
+	if uid == b.failUID {
+		return errors.New("disk full")
+	}
+	return b.StorageBackend.Save(ctx, kind, uid, data)
+}
+
+func newTestBackend(t *testing.T, failUID string) *failingBackend {
+	t.Helper()
+	files, err := fabricaStorage.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &failingBackend{StorageBackend: files, failUID: failUID}
+}
+
+// load returns the stored data for uid, or "" if it does not exist.
+func load(t *testing.T, backend fabricaStorage.StorageBackend, uid string) string {
+	t.Helper()
+	data, err := backend.Load(context.Background(), "Device", uid)
+	if errors.Is(err, fabricaStorage.ErrNotFound) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTxCommit(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestBackend(t, "")
+	if err := backend.Save(ctx, "Device", "dev-1", []byte(`{"v":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := Begin(backend)
+	tx.Save(ctx, "Device", "dev-1", []byte(`{"v":2}`))
+	tx.Save(ctx, "Device", "dev-2", []byte(`{"v":1}`))
+	tx.Save(ctx, "Device", "dev-2", []byte(`{"v":2}`)) // Replaces the staged write
+	if tx.Len() != 2 {
+		t.Errorf("Len = %d, want 2", tx.Len())
+	}
+	if got := load(t, backend, "dev-2"); got != "" {
+		t.Errorf("dev-2 = %s before Commit, want nothing written", got)
+	}
+	if err := tx.Commit(ctx); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	for _, uid := range []string{"dev-1", "dev-2"} {
+		if got := load(t, backend, uid); got != `{"v":2}` {
+			t.Errorf("%s = %s, want {\"v\":2}", uid, got)
+		}
+	}
+
+	if err := tx.Save(ctx, "Device", "dev-3", []byte(`{}`)); err == nil {
+		t.Error("Save after Commit succeeded, want an error")
+	}
+	if err := tx.Commit(ctx); err == nil {
+		t.Error("second Commit succeeded, want an error")
+	}
+}
+
+func TestTxCommitRollsBack(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestBackend(t, "dev-3")
+	if err := backend.Save(ctx, "Device", "dev-1", []byte(`{"v":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// dev-1 is updated and dev-2 created before the write of dev-3 fails
+	tx := Begin(backend)
+	tx.Save(ctx, "Device", "dev-1", []byte(`{"v":2}`))
+	tx.Save(ctx, "Device", "dev-2", []byte(`{"v":2}`))
+	tx.Save(ctx, "Device", "dev-3", []byte(`{"v":2}`))
+	tx.Save(ctx, "Device", "dev-4", []byte(`{"v":2}`))
+	before := backend.saves
+	err := tx.Commit(ctx)
+	if err == nil {
+		t.Fatal("Commit succeeded, want the dev-3 error")
+	}
+
+	if got := load(t, backend, "dev-1"); got != `{"v":1}` {
+		t.Errorf("dev-1 = %s, want its previous data restored", got)
+	}
+	for _, uid := range []string{"dev-2", "dev-3", "dev-4"} {
+		if got := load(t, backend, uid); got != "" {
+			t.Errorf("%s = %s, want it removed", uid, got)
+		}
+	}
+	// dev-1, dev-2, the failed dev-3, then the restore of dev-1; dev-4 is never tried
+	if got := backend.saves - before; got != 4 {
+		t.Errorf("saves = %d, want 4", got)
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	ctx := context.Background()
+	backend := newTestBackend(t, "")
+	tx := Begin(backend)
+	tx.Save(ctx, "Device", "dev-1", []byte(`{"v":1}`))
+	tx.Rollback()
+	if err := tx.Commit(ctx); err == nil {
+		t.Error("Commit after Rollback succeeded, want an error")
+	}
+	if got := load(t, backend, "dev-1"); got != "" {
+		t.Errorf("dev-1 = %s after Rollback, want nothing written", got)
+	}
+}
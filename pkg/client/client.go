@@ -0,0 +1,50 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+//
+// Hand-written client methods for the custom (non-CRUD) server routes.
+// See cmd/server/routes.go.
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+)
+
+// ReprocessDiscoverySnapshot asks the server to run a DiscoverySnapshot through reconciliation again
+func (c *Client) ReprocessDiscoverySnapshot(ctx context.Context, uid string) (*discoverysnapshot.DiscoverySnapshot, error) {
+	var result discoverysnapshot.DiscoverySnapshot
+	endpoint := fmt.Sprintf("/discoverysnapshots/%s/reprocess", uid)
+	if err := c.doRequest(ctx, "POST", endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetLatestDiscoverySnapshot returns the most recently collected DiscoverySnapshot for a source.
+// If phase is not empty, only snapshots in that phase are considered.
+func (c *Client) GetLatestDiscoverySnapshot(ctx context.Context, source, phase string) (*discoverysnapshot.DiscoverySnapshot, error) {
+	var result discoverysnapshot.DiscoverySnapshot
+	query := url.Values{"source": {source}}
+	if phase != "" {
+		query.Set("phase", phase)
+	}
+	endpoint := "/discoverysnapshots/latest?" + query.Encode()
+	if err := c.doRequest(ctx, "GET", endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DiffDiscoverySnapshots returns the device-level changes between two DiscoverySnapshots
+func (c *Client) DiffDiscoverySnapshots(ctx context.Context, fromUID, toUID string) (*discoverysnapshot.SnapshotDiff, error) {
+	var result discoverysnapshot.SnapshotDiff
+	endpoint := fmt.Sprintf("/discoverysnapshots/%s/diff/%s", fromUID, toUID)
+	if err := c.doRequest(ctx, "GET", endpoint, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
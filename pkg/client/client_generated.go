@@ -61,6 +61,7 @@ import (
 	"net/http"
 	"net/url"
 	"path"
+	"strings" // <<< FIX: Used by doRequest to split query strings
 )
 
 // Client provides access to the inventory API
@@ -113,6 +114,8 @@ func (c *Client) doRequest(ctx context.Context, method, endpoint string, body in
 	}
 
 	u := *c.baseURL
+	// <<< FIX: Split off any query string so it is not escaped into the path
+	endpoint, u.RawQuery, _ = strings.Cut(endpoint, "?")
 	u.Path = path.Join(u.Path, endpoint)
 
 	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
@@ -5,15 +5,14 @@ package collector
 
 import (
 	"context"
-	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	// Import the Fabrica-generated client (the SDK)
@@ -22,6 +21,8 @@ import (
 	"github.com/user/inventory-api/pkg/resources/device"
 	// Import the NEW snapshot resource definition
 	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	// Import the snapshot payload envelope
+	"github.com/user/inventory-api/pkg/snapshotformat"
 )
 
 // --- Configuration ---
@@ -29,53 +30,221 @@ import (
 // InventoryAPIHost is the address of the Fabrica API server.
 const InventoryAPIHost = "http://localhost:8081"
 
-// DefaultUsername and DefaultPassword are hardcoded for Redfish basic auth.
-const DefaultUsername = "root"
-const DefaultPassword = "initial0" // Make sure this is your correct password
+// Version is the collector version recorded in snapshot provenance.
+// Release builds set it with -ldflags "-X github.com/user/inventory-api/pkg/collector.Version=...".
+var Version = "dev"
+
+// collectorName identifies this tool in snapshot provenance.
+const collectorName = "inventory-collector"
+
+// DefaultWorkers is how many BMCs CollectAll reads at once when Options.Workers is unset.
+const DefaultWorkers = 10
+
+// DefaultTargetTimeout bounds one BMC's collection when Options.Timeout is unset.
+const DefaultTargetTimeout = 5 * time.Minute
 
 // --- Main Orchestration Function ---
 
+// Options controls a multi-BMC collection run.
+type Options struct {
+	Workers     int           // BMCs collected concurrently
+	Timeout     time.Duration // Upper bound on discovering and posting one BMC
+	Concurrency int           // Requests in flight to any one BMC; 0 means DefaultMaxConcurrency
+	Auth        string        // AuthSession or AuthBasic; "" means AuthSession
+	NoExpand    bool          // Never use $expand, even where the service advertises it
+
+	RequestTimeout time.Duration // Bound on each Redfish request; 0 means DefaultRequestTimeout
+	Retry          RetryPolicy   // Retries of transient failures; zero means DefaultRetryPolicy
+
+	// Credentials supplies the login for targets without their own; nil means EnvCredentials.
+	Credentials CredentialProvider
+	// TLS controls certificate verification; a target's own Fingerprint overrides TLS.Fingerprint.
+	TLS TLSOptions
+	// Output, if set, saves each snapshot under this file or directory (see
+	// SaveSnapshot) instead of posting it, for upload later.
+	Output string
+	// Record, if set, saves every Redfish response under this directory, one
+	// recording per BMC (see NewReplayClient).
+	Record string
+	// Replay, if set, reads each target from the recording named by its
+	// Address in this directory instead of from the network.
+	Replay string
+	// PropertyMap picks the Redfish fields copied into device properties;
+	// nil means DefaultPropertyMap.
+	PropertyMap PropertyMap
+}
+
+// Result is the outcome of collecting one target.
+type Result struct {
+	Target      string
+	SnapshotUID string
+	File        string // Where the snapshot was saved, with Options.Output
+	Devices     int
+	Warnings    int
+	Duration    time.Duration
+	Err         error
+}
+
 // CollectAndPost is the main function for the collector.
 // It connects to a BMC, discovers hardware, and posts it as a single Snapshot.
-func CollectAndPost(bmcIP string) error {
+func CollectAndPost(bmcIP string, creds CredentialProvider) error {
+	sdkClient, err := fabricaclient.NewClient(InventoryAPIHost, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create fabrica client: %w", err)
+	}
+	return collectTarget(context.Background(), sdkClient, Target{Address: bmcIP}, Options{Credentials: creds}).Err
+}
+
+// CollectAll collects from every target using a bounded pool of workers and
+// posts (or, with Options.Output, saves) one snapshot per BMC. A BMC that fails is reported in its Result and
+// does not stop the others. Results are returned in target order.
+func CollectAll(ctx context.Context, targets []Target, opts Options) ([]Result, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = DefaultWorkers
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTargetTimeout
+	}
+	sdkClient, err := fabricaclient.NewClient(InventoryAPIHost, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fabrica client: %w", err)
+	}
+
+	results := make([]Result, len(targets))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers && w < len(targets); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				targetCtx, cancel := context.WithTimeout(ctx, opts.Timeout)
+				results[i] = collectTarget(targetCtx, sdkClient, targets[i], opts)
+				cancel()
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results, nil
+}
+
+// collectTarget discovers one BMC and posts its snapshot.
+func collectTarget(ctx context.Context, sdkClient *fabricaclient.Client, target Target, opts Options) (result Result) {
+	start := time.Now()
+	result.Target = target.Address
+	defer func() { result.Duration = time.Since(start) }()
+
 	// 1. Initialize Redfish Client
-	rfClient, err := NewRedfishClient(bmcIP, DefaultUsername, DefaultPassword)
+	var creds CredentialProvider = StaticCredentials{Username: target.Username, Password: target.Password}
+	if target.Username == "" && target.Password == "" {
+		creds = opts.Credentials
+	}
+	tlsOpts := opts.TLS
+	if target.Fingerprint != "" {
+		tlsOpts.Fingerprint = target.Fingerprint
+	}
+	var rfClient *RedfishClient
+	var err error
+	if opts.Replay != "" {
+		var dir string
+		if dir, err = recordingDir(opts.Replay, target.Address); err == nil {
+			rfClient, err = NewReplayClient(dir)
+		}
+	} else {
+		rfClient, err = NewRedfishClient(ctx, target.Address, creds, tlsOpts)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to initialize Redfish client: %w", err)
+		result.Err = fmt.Errorf("failed to initialize Redfish client: %w", err)
+		return result
+	}
+	if opts.Record != "" {
+		if err := rfClient.recordTo(opts.Record); err != nil {
+			result.Err = err
+			return result
+		}
+	}
+	if opts.Auth != "" {
+		rfClient.AuthMode = opts.Auth
+	}
+	if opts.RequestTimeout > 0 {
+		rfClient.RequestTimeout = opts.RequestTimeout
+	}
+	if opts.Retry.MaxAttempts > 0 {
+		rfClient.Retry = opts.Retry
+	}
+	defer func() {
+		if err := rfClient.Close(); err != nil {
+			rfClient.logf("Warning: %v", err)
+		}
+	}()
+	if opts.Concurrency > 0 {
+		rfClient.MaxConcurrency = opts.Concurrency
+	}
+	if opts.PropertyMap != nil {
+		rfClient.PropertyMap = opts.PropertyMap
 	}
 
-	fmt.Println("Starting Redfish discovery...")
+	rfClient.logf("Starting Redfish discovery...")
+	provenance := &snapshotformat.Provenance{
+		Collector:        collectorName,
+		CollectorVersion: Version,
+		Source:           rfClient.Address,
+		StartedAt:        time.Now().UTC(),
+	}
 
 	// --- 2. REDFISH DISCOVERY (Live Call) ---
+	// Record the service identity first; a BMC that hides it is still worth walking
+	root, err := getServiceRoot(ctx, rfClient)
+	if err != nil {
+		rfClient.warnf("/", "failed to read service root: %v", err)
+	} else {
+		provenance.RedfishVersion = root.RedfishVersion
+		provenance.ServiceUUID = root.UUID
+		if !opts.NoExpand {
+			rfClient.ExpandQuery = expandQuery(root.ProtocolFeaturesSupported.ExpandQuery)
+		}
+	}
+
 	// This function will now just return the list of discovered devices
-	deviceStatuses, err := discoverDevices(rfClient)
+	deviceStatuses, err := discoverDevices(ctx, rfClient, root)
 	if err != nil {
-		return fmt.Errorf("redfish discovery failed: %w", err)
+		result.Err = fmt.Errorf("redfish discovery failed: %w", err)
+		return result
 	}
 	if len(deviceStatuses) == 0 {
-		return errors.New("redfish discovery found no devices to post")
+		result.Err = errors.New("redfish discovery found no devices to post")
+		return result
 	}
-	fmt.Printf("Redfish Discovery Complete: Found %d total devices.\n", len(deviceStatuses))
+	provenance.CompletedAt = time.Now().UTC()
+	provenance.Warnings = rfClient.warnings
+	result.Devices = len(deviceStatuses)
+	result.Warnings = len(provenance.Warnings)
+	rfClient.logf("Redfish Discovery Complete: Found %d total devices with %d warnings.", result.Devices, result.Warnings)
 
 	// --- 3. PREPARE SNAPSHOT PAYLOAD ---
-	// Marshal the list of discovered devices into a single JSON byte slice
-	snapshotData, err := json.Marshal(deviceStatuses)
+	// Marshal the list of discovered devices into a versioned snapshot envelope
+	deviceData, err := json.Marshal(deviceStatuses)
 	if err != nil {
-		return fmt.Errorf("failed to marshal device list into snapshot data: %w", err)
+		result.Err = fmt.Errorf("failed to marshal device list into snapshot data: %w", err)
+		return result
 	}
-
-	// --- 4. INITIALIZE API CLIENT (THE SDK) ---
-	sdkClient, err := fabricaclient.NewClient(InventoryAPIHost, nil)
+	snapshotData, err := json.Marshal(snapshotformat.Envelope{
+		Format:     snapshotformat.FormatRedfishCollectorV1,
+		Provenance: provenance,
+		Data:       deviceData,
+	})
 	if err != nil {
-		return fmt.Errorf("failed to create fabrica client: %w", err)
+		result.Err = fmt.Errorf("failed to marshal device list into snapshot data: %w", err)
+		return result
 	}
-	ctx := context.Background()
-
-	// --- 5. POST THE SNAPSHOT ---
-	fmt.Println("Creating new DiscoverySnapshot resource...")
 
 	// Create the Spec for the new snapshot
 	snapshotSpec := discoverysnapshot.DiscoverySnapshotSpec{
+		Source:  rfClient.Address, // Scopes Redfish URIs to this BMC during reconciliation
 		RawData: json.RawMessage(snapshotData),
 	}
 
@@ -83,168 +252,183 @@ func CollectAndPost(bmcIP string) error {
 	// and the 'Spec' fields.
 	// <<< FIX: The Create*Request struct embeds the Spec struct directly.
 	createReq := fabricaclient.CreateDiscoverySnapshotRequest{
-		Name:                  fmt.Sprintf("snapshot-%s-%d", bmcIP, time.Now().Unix()),
+		Name:                  fmt.Sprintf("snapshot-%s-%d", rfClient.Address, time.Now().Unix()),
 		DiscoverySnapshotSpec: snapshotSpec, // <<< FIX: Use the embedded struct type name
 	}
 
+	// --- 4. SAVE OR POST THE SNAPSHOT ---
+	if opts.Output != "" {
+		file, err := SaveSnapshot(createReq, opts.Output)
+		if err != nil {
+			result.Err = err
+			return result
+		}
+		result.File = file
+		rfClient.logf("Saved snapshot to %s", file)
+		return result
+	}
+
 	// Use the SDK to create the snapshot resource
+	rfClient.logf("Creating new DiscoverySnapshot resource...")
 	createdSnapshot, err := sdkClient.CreateDiscoverySnapshot(ctx, createReq)
 	if err != nil {
-		return fmt.Errorf("failed to create snapshot: %w", err)
+		result.Err = fmt.Errorf("failed to create snapshot: %w", err)
+		return result
 	}
+	result.SnapshotUID = createdSnapshot.Metadata.UID
 
-	fmt.Printf("Successfully created snapshot with UID: %s\n", createdSnapshot.Metadata.UID)
-	fmt.Println("The server reconciler will now process this snapshot.")
-
-	return nil
+	rfClient.logf("Successfully created snapshot with UID: %s", result.SnapshotUID)
+	return result
 }
 
 // --- Redfish Client Struct and Methods ---
 
-// NewRedfishClient initializes the client with a specified BMC IP.
-func NewRedfishClient(bmcIP, username, password string) (*RedfishClient, error) {
-	baseURL := fmt.Sprintf("https://%s/redfish/v1", bmcIP)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	return &RedfishClient{
-		BaseURL:    baseURL,
-		Username:   username,
-		Password:   password,
-		HTTPClient: &http.Client{Transport: tr},
-	}, nil
-}
-
-// Get makes an authenticated GET request to a Redfish path.
-func (c *RedfishClient) Get(path string) ([]byte, error) {
-	targetURL, err := url.JoinPath(c.BaseURL, path)
+// NewRedfishClient initializes the client for a BMC address, looking its
+// login up with creds (EnvCredentials if nil). The address is host[:port],
+// optionally prefixed with http:// or https:// (the default).
+func NewRedfishClient(ctx context.Context, bmcIP string, creds CredentialProvider, tlsOpts TLSOptions) (*RedfishClient, error) {
+	scheme, host, err := splitScheme(bmcIP)
 	if err != nil {
-		return nil, fmt.Errorf("failed to join path: %w", err)
+		return nil, err
 	}
-	req, err := http.NewRequest(http.MethodGet, targetURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Redfish request for %s: %w", targetURL, err)
+	if creds == nil {
+		creds = EnvCredentials{}
+	}
+	login, err := creds.Credentials(ctx, host)
+	if errors.Is(err, ErrNoCredentials) {
+		return nil, fmt.Errorf("no credentials for %s", host)
 	}
-	req.SetBasicAuth(c.Username, c.Password)
-	req.Header.Add("Accept", "application/json")
-	resp, err := c.HTTPClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute Redfish request for %s: %w", targetURL, err)
+		return nil, fmt.Errorf("failed to get credentials for %s: %w", host, err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Redfish API returned status code %d for %s", resp.StatusCode, targetURL)
+
+	c := &RedfishClient{
+		Address:        host,
+		BaseURL:        fmt.Sprintf("%s://%s/redfish/v1", scheme, host),
+		Username:       login.Username,
+		Password:       login.Password,
+		MaxConcurrency: DefaultMaxConcurrency,
+		AuthMode:       AuthSession,
+		RequestTimeout: DefaultRequestTimeout,
+		Retry:          DefaultRetryPolicy,
+		PropertyMap:    DefaultPropertyMap,
 	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.Proxy = nil
+	if scheme == "https" {
+		tr.TLSClientConfig = tlsOpts.tlsConfig(host, c.logf)
 	}
-	return body, nil
+	c.HTTPClient = &http.Client{Transport: tr}
+	return c, nil
 }
 
-// --- Redfish Discovery and Mapping Functions ---
-
-// discoverDevices uses the Redfish client to walk the resource hierarchy.
-// It now returns only the list of device status structs.
-func discoverDevices(c *RedfishClient) ([]*device.DeviceStatus, error) {
-	var statuses []*device.DeviceStatus
-
-	systemsBody, err := c.Get("/Systems")
+// Get makes an authenticated GET request to a Redfish path. The path may
+// carry a query string, e.g. "/Systems?$expand=.". Each attempt is bounded by
+// RequestTimeout; transient failures are retried as RetryPolicy allows, and a
+// 401 on a session token gets one fresh login.
+func (c *RedfishClient) Get(ctx context.Context, path string) ([]byte, error) {
+	path, query, _ := strings.Cut(path, "?")
+	targetURL, err := url.JoinPath(c.BaseURL, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get Systems collection: %w", err)
+		return nil, fmt.Errorf("failed to join path: %w", err)
 	}
-	var systemsCollection RedfishCollection
-	if err := json.Unmarshal(systemsBody, &systemsCollection); err != nil {
-		return nil, fmt.Errorf("failed to decode Systems collection: %w", err)
+	if query != "" {
+		targetURL += "?" + query
 	}
 
-	for _, member := range systemsCollection.Members {
-		systemURI := strings.TrimPrefix(member.ODataID, "/redfish/v1")
-		systemInventory, err := getSystemInventory(c, systemURI)
-		if err != nil {
-			fmt.Printf("Warning: Failed to get inventory for system %s: %v\n", member.ODataID, err)
+	reauthenticated := false
+	for attempt := 1; ; attempt++ {
+		status, header, body, token, err := c.getOnce(ctx, targetURL)
+		if err == nil && status == http.StatusOK {
+			return body, nil
+		}
+		if err == nil && status == http.StatusUnauthorized && token != "" && !reauthenticated {
+			// A session can expire mid-walk; log in again without spending an attempt.
+			c.expireSession(token)
+			reauthenticated = true
+			attempt--
 			continue
 		}
 
-		// Add the Node's status
-		statuses = append(statuses, systemInventory.NodeStatus)
-		// Add all child statuses
-		statuses = append(statuses, systemInventory.CPUs...)
-		statuses = append(statuses, systemInventory.DIMMs...)
+		var wait time.Duration
+		if err != nil {
+			err = fmt.Errorf("failed to execute Redfish request for %s: %w", targetURL, err)
+		} else {
+			err = &statusError{Code: status, URL: targetURL}
+			wait = retryAfter(header.Get("Retry-After"), time.Now())
+		}
+		if !retryableError(ctx, err) || attempt >= c.Retry.MaxAttempts {
+			return nil, err
+		}
+		// A BMC asking for a longer wait than we may spend is not waited on.
+		if limit := c.Retry.retryAfterLimit(ctx, time.Now()); limit >= 0 && wait > limit {
+			return nil, fmt.Errorf("%w (Retry-After %s exceeds the %s left to wait)", err, wait, limit.Round(time.Millisecond))
+		}
+		if backoff := c.Retry.backoff(attempt); wait < backoff {
+			wait = backoff
+		}
+		c.logf("Retrying in %s (attempt %d of %d): %v", wait.Round(time.Millisecond), attempt+1, c.Retry.MaxAttempts, err)
+		if err := sleep(ctx, wait); err != nil {
+			return nil, fmt.Errorf("gave up on %s: %w", targetURL, err)
+		}
 	}
-	return statuses, nil
 }
 
-// getSystemInventory discovers a single system (Node) and its children.
-func getSystemInventory(c *RedfishClient, systemURI string) (*SystemInventory, error) {
-	inv := &SystemInventory{CPUs: make([]*device.DeviceStatus, 0), DIMMs: make([]*device.DeviceStatus, 0)}
-	systemBody, err := c.Get(systemURI)
+// getOnce makes a single authenticated GET, bounded by RequestTimeout, and
+// reads the whole body. It returns the session token it used, if any.
+func (c *RedfishClient) getOnce(ctx context.Context, targetURL string) (int, http.Header, []byte, string, error) {
+	if c.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.RequestTimeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, targetURL, nil)
 	if err != nil {
-		return nil, err
+		return 0, nil, nil, "", fmt.Errorf("failed to create Redfish request: %w", err)
 	}
-	var systemData RedfishSystem
-	if err := json.Unmarshal(systemBody, &systemData); err != nil {
-		return nil, fmt.Errorf("failed to decode system data from %s: %w", systemURI, err)
+	req.Header.Add("Accept", "application/json")
+	token, err := c.authorize(req)
+	if err != nil {
+		return 0, nil, nil, "", err
 	}
-
-	// Map Node Data, including its parent Redfish URI (which is the systemURI itself)
-	inv.NodeStatus = mapCommonProperties(
-		systemData.CommonRedfishProperties,
-		"Node",
-		systemURI,
-		"", // Node is the parent
-	)
-
-	// Get Processors (CPUs)
-	if cpuCollectionURI := systemData.Processors.ODataID; cpuCollectionURI != "" {
-		cleanedURI := strings.TrimPrefix(cpuCollectionURI, "/redfish/v1")
-		cpuDevices, err := getCollectionDevices(c, cleanedURI, "CPU", systemURI, &RedfishProcessor{})
-		if err != nil {
-			fmt.Printf("Warning: Failed to retrieve CPU inventory from %s: %v\n", cpuCollectionURI, err)
-		} else {
-			inv.CPUs = cpuDevices
-		}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, nil, nil, token, err
 	}
-	// Get Memory (DIMMs)
-	if dimmCollectionURI := systemData.Memory.ODataID; dimmCollectionURI != "" {
-		cleanedURI := strings.TrimPrefix(dimmCollectionURI, "/redfish/v1")
-		dimmDevices, err := getCollectionDevices(c, cleanedURI, "DIMM", systemURI, &RedfishMemory{})
-		if err != nil {
-			fmt.Printf("Warning: Failed to retrieve DIMM inventory from %s: %v\n", dimmCollectionURI, err)
-		} else {
-			inv.DIMMs = dimmDevices
-		}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, token, fmt.Errorf("failed to read response body: %w", err)
 	}
-	return inv, nil
+	return resp.StatusCode, resp.Header, body, token, nil
+}
+
+// logf prints a progress line tagged with the BMC address, so output from
+// concurrent collections can be told apart.
+func (c *RedfishClient) logf(format string, args ...interface{}) {
+	fmt.Printf("[%s] %s\n", c.Address, fmt.Sprintf(format, args...))
 }
 
-// getCollectionDevices retrieves a collection, iterates over members, and maps them.
-func getCollectionDevices(c *RedfishClient, collectionURI, deviceType, parentURI string, componentTypeExample interface{}) ([]*device.DeviceStatus, error) {
-	var statuses []*device.DeviceStatus
-	collectionBody, err := c.Get(collectionURI)
+// warnf records a non-fatal problem reading uri and prints it.
+func (c *RedfishClient) warnf(uri, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	c.logf("Warning: %s", msg)
+	c.warnings = append(c.warnings, snapshotformat.Warning{URI: uri, Message: msg})
+}
+
+// --- Redfish Discovery and Mapping Functions ---
+
+// getServiceRoot reads the Redfish service root.
+func getServiceRoot(ctx context.Context, c *RedfishClient) (*RedfishServiceRoot, error) {
+	body, err := c.Get(ctx, "/")
 	if err != nil {
 		return nil, err
 	}
-	var collection RedfishCollection
-	if err := json.Unmarshal(collectionBody, &collection); err != nil {
-		return nil, fmt.Errorf("failed to decode collection from %s: %w", collectionURI, err)
-	}
-	for _, member := range collection.Members {
-		memberURI := strings.TrimPrefix(member.ODataID, "/redfish/v1")
-		memberBody, err := c.Get(memberURI)
-		if err != nil {
-			fmt.Printf("Warning: Failed to get member %s: %v\n", member.ODataID, err)
-			continue
-		}
-		component := reflect.New(reflect.TypeOf(componentTypeExample).Elem()).Interface()
-		if err := json.Unmarshal(memberBody, &component); err != nil {
-			fmt.Printf("Warning: Failed to unmarshal component %s: %v\n", member.ODataID, err)
-			continue
-		}
-		rfProps := reflect.ValueOf(component).Elem().Field(0).Interface().(CommonRedfishProperties)
-		statuses = append(statuses, mapCommonProperties(rfProps, deviceType, memberURI, parentURI))
+	root := &RedfishServiceRoot{}
+	if err := json.Unmarshal(body, root); err != nil {
+		return nil, fmt.Errorf("failed to decode service root: %w", err)
 	}
-	return statuses, nil
+	return root, nil
 }
 
 // mapCommonProperties maps Redfish fields to the API's DeviceStatus struct.
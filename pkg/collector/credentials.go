@@ -0,0 +1,214 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- Credential Providers ---
+
+// ErrNoCredentials is returned by a CredentialProvider that has nothing for an address.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Credentials are the Redfish login for one BMC.
+type Credentials struct {
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// CredentialProvider looks up the credentials for a BMC address. It returns
+// ErrNoCredentials (possibly wrapped) when it has none, so providers can be chained.
+type CredentialProvider interface {
+	Credentials(ctx context.Context, address string) (Credentials, error)
+}
+
+// StaticCredentials returns the same credentials for every address.
+type StaticCredentials Credentials
+
+// Credentials implements CredentialProvider.
+func (s StaticCredentials) Credentials(ctx context.Context, address string) (Credentials, error) {
+	if s.Username == "" && s.Password == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return Credentials(s), nil
+}
+
+// Environment variables read by EnvCredentials.
+const (
+	EnvUsername = "REDFISH_USERNAME"
+	EnvPassword = "REDFISH_PASSWORD"
+)
+
+// EnvCredentials reads one username and password for every BMC from
+// REDFISH_USERNAME and REDFISH_PASSWORD.
+type EnvCredentials struct{}
+
+// Credentials implements CredentialProvider.
+func (EnvCredentials) Credentials(ctx context.Context, address string) (Credentials, error) {
+	creds := Credentials{Username: os.Getenv(EnvUsername), Password: os.Getenv(EnvPassword)}
+	if creds.Username == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return creds, nil
+}
+
+// ChainCredentials asks each provider in turn and returns the first credentials found.
+type ChainCredentials []CredentialProvider
+
+// Credentials implements CredentialProvider.
+func (chain ChainCredentials) Credentials(ctx context.Context, address string) (Credentials, error) {
+	for _, provider := range chain {
+		creds, err := provider.Credentials(ctx, address)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return creds, err
+	}
+	return Credentials{}, ErrNoCredentials
+}
+
+// credentialsEntry is one rule in a credentials file.
+type credentialsEntry struct {
+	Match       string `yaml:"match"`
+	Credentials `yaml:",inline"`
+
+	prefix netip.Prefix // Set when Match is a CIDR
+}
+
+// FileCredentials holds per-BMC credentials loaded from a YAML or JSON file:
+//
+//	credentials:
+//	  - match: 10.0.0.5          # exact address
+//	    username: admin
+//	    password: secret
+//	  - match: 10.0.1.0/24       # CIDR
+//	    username: root
+//	    password: other
+//	  - match: "x1000c*b0"       # glob, as in path.Match
+//	    username: root
+//	    password: blade
+//
+// An exact match wins over a CIDR, the longest matching CIDR wins over a
+// shorter one, and a CIDR wins over a glob. Globs are tried in file order.
+type FileCredentials struct {
+	entries []credentialsEntry
+}
+
+// LoadCredentialsFile reads a credentials file.
+func LoadCredentialsFile(filename string) (*FileCredentials, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	var file struct {
+		Credentials []credentialsEntry `yaml:"credentials"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file %s: %w", filename, err)
+	}
+	for i := range file.Credentials {
+		entry := &file.Credentials[i]
+		if entry.Match == "" {
+			return nil, fmt.Errorf("credentials file %s: entry %d has no match", filename, i+1)
+		}
+		if strings.Contains(entry.Match, "/") {
+			prefix, err := netip.ParsePrefix(entry.Match)
+			if err != nil {
+				return nil, fmt.Errorf("credentials file %s: entry %d: invalid CIDR %q: %w", filename, i+1, entry.Match, err)
+			}
+			entry.prefix = prefix.Masked()
+		} else if _, err := path.Match(entry.Match, ""); err != nil {
+			return nil, fmt.Errorf("credentials file %s: entry %d: invalid pattern %q: %w", filename, i+1, entry.Match, err)
+		}
+	}
+	return &FileCredentials{entries: file.Credentials}, nil
+}
+
+// Credentials implements CredentialProvider. An address with a port is also
+// matched on its host alone.
+func (f *FileCredentials) Credentials(ctx context.Context, address string) (Credentials, error) {
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	addr, addrErr := netip.ParseAddr(host)
+
+	// Rank each match: exact beats any CIDR, a longer CIDR beats a shorter
+	// one, and any CIDR beats a glob.
+	best, bestRank := -1, -2
+	for i, e := range f.entries {
+		rank := -2
+		switch {
+		case e.Match == address || e.Match == host:
+			rank = 1000
+		case e.prefix.IsValid():
+			if addrErr == nil && e.prefix.Contains(addr) {
+				rank = e.prefix.Bits()
+			}
+		default:
+			if globMatch(e.Match, host) || globMatch(e.Match, address) {
+				rank = -1
+			}
+		}
+		if rank > bestRank {
+			best, bestRank = i, rank
+		}
+	}
+	if best < 0 {
+		return Credentials{}, ErrNoCredentials
+	}
+	return f.entries[best].Credentials, nil
+}
+
+// globMatch reports whether name matches pattern; bad patterns never match.
+func globMatch(pattern, name string) bool {
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
+
+// CommandCredentials runs an external helper to fetch credentials, so they
+// can come from a vault or password manager. The helper gets the BMC address
+// as its last argument and in REDFISH_ADDRESS, and prints
+// {"username": "...", "password": "..."} on stdout. Exit status 0 with empty
+// output means it has no credentials for that address.
+type CommandCredentials struct {
+	Command string
+	Args    []string
+}
+
+// Credentials implements CredentialProvider.
+func (c CommandCredentials) Credentials(ctx context.Context, address string) (Credentials, error) {
+	cmd := exec.CommandContext(ctx, c.Command, append(append([]string{}, c.Args...), address)...)
+	cmd.Env = append(os.Environ(), "REDFISH_ADDRESS="+address)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Credentials{}, fmt.Errorf("credentials command %s failed: %w: %s", c.Command, err, msg)
+		}
+		return Credentials{}, fmt.Errorf("credentials command %s failed: %w", c.Command, err)
+	}
+	if len(bytes.TrimSpace(stdout.Bytes())) == 0 {
+		return Credentials{}, ErrNoCredentials
+	}
+	var creds Credentials
+	if err := json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return Credentials{}, fmt.Errorf("credentials command %s printed invalid JSON: %w", c.Command, err)
+	}
+	if creds.Username == "" {
+		return Credentials{}, ErrNoCredentials
+	}
+	return creds, nil
+}
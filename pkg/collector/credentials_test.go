@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeCredentialsFile(t *testing.T, data string) *FileCredentials {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	creds, err := LoadCredentialsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return creds
+}
+
+func TestFileCredentials(t *testing.T) {
+	creds := writeCredentialsFile(t, `credentials:
+  - match: "10.0.*"
+    username: glob
+  - match: 10.0.0.0/16
+    username: wide
+  - match: 10.0.1.0/24
+    username: narrow
+  - match: 10.0.1.5
+    username: exact
+  - match: "x1000c*b0"
+    username: blade
+  - match: "x1000c1*"
+    username: later-glob
+`)
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"10.0.1.5", "exact"},
+		{"10.0.1.5:8443", "exact"}, // The host alone is matched
+		{"10.0.1.6", "narrow"},
+		{"10.0.2.6", "wide"},
+		{"10.0.2.6:443", "wide"},
+		{"10.0.0.0.example", "glob"}, // Not an IP, so only globs apply
+		{"x1000c1s0b0", "blade"},     // Globs are tried in file order
+		{"192.168.0.1", ""},
+	}
+	for _, tt := range tests {
+		got, err := creds.Credentials(context.Background(), tt.address)
+		if tt.want == "" {
+			if !errors.Is(err, ErrNoCredentials) {
+				t.Errorf("Credentials(%s) = %+v, %v, want ErrNoCredentials", tt.address, got, err)
+			}
+			continue
+		}
+		if err != nil || got.Username != tt.want {
+			t.Errorf("Credentials(%s) = %+v, %v, want username %s", tt.address, got, err, tt.want)
+		}
+	}
+}
+
+func TestLoadCredentialsFileErrors(t *testing.T) {
+	for _, data := range []string{
+		"credentials:\n  - username: admin\n",
+		"credentials:\n  - match: 10.0.0.0/40\n",
+		"credentials:\n  - match: \"[\"\n",
+	} {
+		path := filepath.Join(t.TempDir(), "credentials.yaml")
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadCredentialsFile(path); err == nil {
+			t.Errorf("LoadCredentialsFile accepted %q", data)
+		}
+	}
+}
+
+func TestChainCredentials(t *testing.T) {
+	t.Setenv(EnvUsername, "")
+	chain := ChainCredentials{EnvCredentials{}, StaticCredentials{}, StaticCredentials{Username: "fallback"}}
+	got, err := chain.Credentials(context.Background(), "10.0.0.1")
+	if err != nil || got.Username != "fallback" {
+		t.Errorf("Credentials = %+v, %v, want the fallback", got, err)
+	}
+
+	t.Setenv(EnvUsername, "env")
+	got, err = chain.Credentials(context.Background(), "10.0.0.1")
+	if err != nil || got.Username != "env" {
+		t.Errorf("Credentials = %+v, %v, want the environment", got, err)
+	}
+
+	if _, err := (ChainCredentials{}).Credentials(context.Background(), "10.0.0.1"); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("empty chain error = %v, want ErrNoCredentials", err)
+	}
+}
@@ -0,0 +1,140 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// --- Collection Fetching ---
+
+// DefaultMaxConcurrency is how many requests a RedfishClient sends to one BMC
+// at once unless told otherwise. BMCs are small embedded systems; a handful of
+// parallel requests hides TLS round trips without overwhelming them.
+const DefaultMaxConcurrency = 4
+
+// Member is one fetched collection member. Err is set if it could not be read.
+type Member struct {
+	URI  string
+	Body []byte
+	Err  error
+}
+
+// expandQuery picks the $expand query for a service's advertised support.
+// Only one level is asked for: the collection plus its members, not their
+// subordinate collections.
+func expandQuery(f RedfishExpandQuery) string {
+	var q string
+	switch {
+	case f.NoLinks:
+		q = "."
+	case f.ExpandAll:
+		q = "*"
+	default:
+		return ""
+	}
+	if f.Levels {
+		q += "($levels=1)"
+	}
+	return "$expand=" + q
+}
+
+// GetCollection fetches the collection at path and each of its members, in
+// collection order. With ExpandQuery set the members arrive inline in one
+// request; any member the service left as a bare link, and every member
+// otherwise, is fetched with up to MaxConcurrency requests at a time. Members
+// for which skip returns true are not fetched or returned.
+func (c *RedfishClient) GetCollection(ctx context.Context, path string, skip func(uri string) bool) ([]Member, error) {
+	query := ""
+	if c.ExpandQuery != "" {
+		query = "?" + c.ExpandQuery
+	}
+	body, err := c.Get(ctx, path+query)
+	if err != nil {
+		return nil, err
+	}
+	var collection RedfishExpandedCollection
+	if err := json.Unmarshal(body, &collection); err != nil {
+		return nil, fmt.Errorf("failed to decode collection %s: %w", path, err)
+	}
+
+	members := make([]Member, 0, len(collection.Members))
+	var pending []int
+	for _, raw := range collection.Members {
+		var link ODataLink
+		if err := json.Unmarshal(raw, &link); err != nil {
+			return nil, fmt.Errorf("failed to decode member of %s: %w", path, err)
+		}
+		uri := cleanURI(link.ODataID)
+		if uri == "" || (skip != nil && skip(uri)) {
+			continue
+		}
+		member := Member{URI: uri}
+		if c.ExpandQuery != "" && isExpanded(raw) {
+			member.Body = raw
+		} else {
+			pending = append(pending, len(members))
+		}
+		members = append(members, member)
+	}
+	c.fetch(ctx, members, pending)
+	return members, nil
+}
+
+// GetAll fetches every uri, with up to MaxConcurrency requests at a time, and
+// returns them in the order given.
+func (c *RedfishClient) GetAll(ctx context.Context, uris []string) []Member {
+	members := make([]Member, len(uris))
+	pending := make([]int, len(uris))
+	for i, uri := range uris {
+		members[i].URI = uri
+		pending[i] = i
+	}
+	c.fetch(ctx, members, pending)
+	return members
+}
+
+// fetch fills in the Body or Err of members[i] for each pending index.
+func (c *RedfishClient) fetch(ctx context.Context, members []Member, pending []int) {
+	workers := c.MaxConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				members[i].Body, members[i].Err = c.Get(ctx, members[i].URI)
+			}
+		}()
+	}
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// isExpanded reports whether a collection member carries the resource itself
+// rather than just its @odata.id. Some services accept $expand and ignore it.
+func isExpanded(raw json.RawMessage) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return false
+	}
+	for key := range fields {
+		if !strings.HasPrefix(key, "@odata.") {
+			return true
+		}
+	}
+	return false
+}
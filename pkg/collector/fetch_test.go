@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testLogin is the account the fake BMCs accept.
+var testLogin = StaticCredentials{Username: "root", Password: "secret"}
+
+// fakeBMC serves fixed bodies by request URI and counts the requests made.
+type fakeBMC struct {
+	mu       sync.Mutex
+	bodies   map[string]string
+	requests []string
+}
+
+func (f *fakeBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, r.URL.RequestURI())
+	f.mu.Unlock()
+	body, ok := f.bodies[r.URL.RequestURI()]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(body))
+}
+
+func newFakeBMC(t *testing.T, bodies map[string]string) (*fakeBMC, *RedfishClient) {
+	t.Helper()
+	bmc := &fakeBMC{bodies: bodies}
+	srv := httptest.NewTLSServer(bmc)
+	t.Cleanup(srv.Close)
+	c, err := NewRedfishClient(context.Background(), strings.TrimPrefix(srv.URL, "https://"), testLogin, TLSOptions{Insecure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AuthMode = AuthBasic
+	return bmc, c
+}
+
+func TestExpandQuery(t *testing.T) {
+	tests := []struct {
+		f    RedfishExpandQuery
+		want string
+	}{
+		{RedfishExpandQuery{}, ""},
+		{RedfishExpandQuery{NoLinks: true}, "$expand=."},
+		{RedfishExpandQuery{NoLinks: true, ExpandAll: true, Levels: true}, "$expand=.($levels=1)"},
+		{RedfishExpandQuery{ExpandAll: true}, "$expand=*"},
+		{RedfishExpandQuery{Levels: true}, ""},
+	}
+	for _, tt := range tests {
+		if got := expandQuery(tt.f); got != tt.want {
+			t.Errorf("expandQuery(%+v) = %q, want %q", tt.f, got, tt.want)
+		}
+	}
+}
+
+func TestGetCollection(t *testing.T) {
+	bodies := map[string]string{
+		"/redfish/v1/Systems": `{"Members": [
+			{"@odata.id": "/redfish/v1/Systems/1"},
+			{"@odata.id": "/redfish/v1/Systems/2"},
+			{"@odata.id": "/redfish/v1/Systems/3"}]}`,
+		"/redfish/v1/Systems?$expand=.": `{"Members": [
+			{"@odata.id": "/redfish/v1/Systems/1", "Id": "1"},
+			{"@odata.id": "/redfish/v1/Systems/2"},
+			{"@odata.id": "/redfish/v1/Systems/3", "Id": "3"}]}`,
+		"/redfish/v1/Systems/1": `{"Id": "1"}`,
+		"/redfish/v1/Systems/2": `{"Id": "2"}`,
+	}
+
+	tests := []struct {
+		name         string
+		expand       string
+		skip         func(string) bool
+		wantURIs     []string
+		wantRequests int
+	}{
+		{
+			name:         "without expand",
+			wantURIs:     []string{"/Systems/1", "/Systems/2", "/Systems/3"},
+			wantRequests: 4,
+		},
+		{
+			name:         "with expand fetches bare links",
+			expand:       "$expand=.",
+			wantURIs:     []string{"/Systems/1", "/Systems/2", "/Systems/3"},
+			wantRequests: 2,
+		},
+		{
+			name:         "skipped members",
+			skip:         func(uri string) bool { return strings.HasSuffix(uri, "/2") },
+			wantURIs:     []string{"/Systems/1", "/Systems/3"},
+			wantRequests: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmc, c := newFakeBMC(t, bodies)
+			c.ExpandQuery = tt.expand
+			members, err := c.GetCollection(context.Background(), "/Systems", tt.skip)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var uris []string
+			for _, m := range members {
+				uris = append(uris, m.URI)
+			}
+			if !reflect.DeepEqual(uris, tt.wantURIs) {
+				t.Errorf("member URIs = %v, want %v", uris, tt.wantURIs)
+			}
+			if len(bmc.requests) != tt.wantRequests {
+				t.Errorf("made %d requests %v, want %d", len(bmc.requests), bmc.requests, tt.wantRequests)
+			}
+			for _, m := range members {
+				// Systems/3 only exists inline in the expanded collection.
+				if m.URI == "/Systems/3" && tt.expand == "" {
+					if m.Err == nil {
+						t.Errorf("%s: expected a fetch error", m.URI)
+					}
+					continue
+				}
+				if m.Err != nil || !strings.Contains(string(m.Body), `"Id"`) {
+					t.Errorf("%s: body %s, err %v", m.URI, m.Body, m.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestIsExpanded(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{`{"@odata.id": "/redfish/v1/Systems/1"}`, false},
+		{`{"@odata.id": "/redfish/v1/Systems/1", "@odata.type": "#ComputerSystem"}`, false},
+		{`{"@odata.id": "/redfish/v1/Systems/1", "Id": "1"}`, true},
+		{`"not an object"`, false},
+	}
+	for _, tt := range tests {
+		if got := isExpanded([]byte(tt.raw)); got != tt.want {
+			t.Errorf("isExpanded(%s) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	// Import the API's canonical resource definition
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// --- Firmware Inventory ---
+//
+// Each member of the UpdateService's FirmwareInventory is recorded on the device
+// it runs on as firmware.<component>.version, .software_id and .updateable.
+// The device is found through the member's RelatedItem links, walking up
+// from a sub-resource such as /Systems/1/Bios to the device that owns it.
+// Without a usable link the member's Id and Name decide: BIOS goes to the
+// only Node, BMC firmware to the only BMC, and otherwise a word matching a
+// device's Redfish Id (e.g. "NIC1_Firmware" for .../NetworkAdapters/NIC1)
+// picks that device if no other device has the same Id. A BMC without an
+// UpdateService or FirmwareInventory simply has no firmware to record.
+
+// firmwareKinds names the components recognised from a firmware Id or Name,
+// and the device type they belong to when no RelatedItem says.
+var firmwareKinds = []struct {
+	component  string
+	deviceType string
+	words      []string
+}{
+	{"bios", "Node", []string{"bios", "uefi"}},
+	{"bmc", "BMC", []string{"bmc", "manager", "idrac", "ilo", "xcc"}},
+	{"cpld", "", []string{"cpld"}},
+}
+
+// firmware reads the firmware inventory linked from updateService and
+// attaches each version to its device.
+func (w *treeWalker) firmware(ctx context.Context, updateService ODataLink) {
+	updateServiceURI := cleanURI(updateService.ODataID)
+	if updateServiceURI == "" {
+		return
+	}
+	var service RedfishUpdateService
+	if _, err := w.get(ctx, updateServiceURI, &service); err != nil {
+		w.c.warnf(updateServiceURI, "failed to get update service %s: %v", updateServiceURI, err)
+		return
+	}
+	w.eachMember(ctx, service.FirmwareInventory, func(uri string, body []byte) {
+		var item RedfishSoftwareInventory
+		if err := json.Unmarshal(body, &item); err != nil {
+			w.c.warnf(uri, "failed to unmarshal firmware %s: %v", uri, err)
+			return
+		}
+		if item.Version == "" {
+			return
+		}
+		words := nameWords(item.ID + " " + item.Name)
+		status := w.firmwareOwner(item, words)
+		if status == nil {
+			w.c.logf("Firmware %s (%s) matched no device", uri, item.Version)
+			return
+		}
+		prefix := "firmware." + w.firmwareComponent(status, item, words) + "."
+		snapshotformat.SetStringProperty(status, prefix+"version", item.Version)
+		snapshotformat.SetStringProperty(status, prefix+"software_id", item.SoftwareID)
+		if item.Updateable != nil {
+			raw, _ := json.Marshal(*item.Updateable)
+			status.Properties[prefix+"updateable"] = raw
+		}
+	})
+}
+
+// firmwareOwner finds the device a firmware inventory member belongs to.
+func (w *treeWalker) firmwareOwner(item RedfishSoftwareInventory, words map[string]bool) *device.DeviceStatus {
+	for _, link := range item.RelatedItem {
+		if status := w.owner(cleanURI(link.ODataID)); status != nil {
+			return status
+		}
+	}
+	for _, kind := range firmwareKinds {
+		if kind.deviceType != "" && hasAnyWord(words, kind.words) {
+			return w.only(kind.deviceType)
+		}
+	}
+	return w.byRedfishID(words)
+}
+
+// owner returns the reported device at uri or the nearest one above it. A
+// URI merged into another device, like an accelerator's PCIe card, leads to
+// that device.
+func (w *treeWalker) owner(uri string) *device.DeviceStatus {
+	uri, _, _ = strings.Cut(uri, "#")
+	for uri != "" && uri != "/" && uri != "." {
+		if merged, ok := w.mergedInto[uri]; ok {
+			uri = merged
+		}
+		if status := w.byURI[uri]; status != nil {
+			return status
+		}
+		uri = path.Dir(uri)
+	}
+	return nil
+}
+
+// only returns the single device of a type, or nil if there are none or several.
+func (w *treeWalker) only(deviceType string) *device.DeviceStatus {
+	var found *device.DeviceStatus
+	for _, status := range w.statuses {
+		if status.DeviceType != deviceType {
+			continue
+		}
+		if found != nil {
+			return nil
+		}
+		found = status
+	}
+	return found
+}
+
+// byRedfishID returns the device whose Redfish Id, the last element of its
+// URI, is one of words. Ids shared by several devices, or made only of
+// digits like the "1" in /Systems/1 and /Chassis/1, match nothing.
+func (w *treeWalker) byRedfishID(words map[string]bool) *device.DeviceStatus {
+	var found *device.DeviceStatus
+	for _, status := range w.statuses {
+		id := strings.ToLower(path.Base(snapshotformat.StringProperty(status, "redfish_uri")))
+		if strings.Trim(id, "0123456789") == "" || !words[id] {
+			continue
+		}
+		if found != nil && found != status {
+			return nil
+		}
+		found = status
+	}
+	return found
+}
+
+// firmwareComponent names a firmware item within its device's properties:
+// a recognised kind (bios, bmc, cpld), else the device type, else, when the
+// device already has firmware under that name, the item's own Id.
+func (w *treeWalker) firmwareComponent(status *device.DeviceStatus, item RedfishSoftwareInventory, words map[string]bool) string {
+	for _, kind := range firmwareKinds {
+		if hasAnyWord(words, kind.words) {
+			return kind.component
+		}
+	}
+	component := propertyName(status.DeviceType)
+	if _, taken := status.Properties["firmware."+component+".version"]; taken && item.ID != "" {
+		component = propertyName(item.ID)
+	}
+	return component
+}
+
+var nonWordChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// nameWords splits a firmware Id or Name into lowercase words.
+func nameWords(s string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range nonWordChars.Split(strings.ToLower(s), -1) {
+		if word != "" {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+func hasAnyWord(words map[string]bool, candidates []string) bool {
+	for _, candidate := range candidates {
+		if words[candidate] {
+			return true
+		}
+	}
+	return false
+}
+
+// propertyName turns "PowerSupply" or "GPU1 Firmware" into a property key
+// element: "power_supply", "gpu1_firmware".
+func propertyName(s string) string {
+	return strings.Trim(nonWordChars.ReplaceAllString(snapshotformat.SnakeCase(s), "_"), "_")
+}
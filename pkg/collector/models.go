@@ -1,38 +1,80 @@
 package collector
 
 import (
+	"encoding/json"
 	"net/http"
+	"time"
 
-	// Import the API's canonical resource definition
-	"github.com/user/inventory-api/pkg/resources/device"
+	// Import the snapshot payload envelope
+	"github.com/user/inventory-api/pkg/snapshotformat"
 )
 
 // --- Redfish Client Struct ---
 
 // RedfishClient holds connection details and the HTTP client instance.
 type RedfishClient struct {
+	Address    string
 	BaseURL    string
 	Username   string
 	Password   string
 	HTTPClient *http.Client
+
+	// MaxConcurrency caps how many requests the client has in flight to the
+	// BMC at once when fetching collection members; 1 or less fetches them one by one.
+	MaxConcurrency int
+	// RequestTimeout bounds each request attempt; 0 means no limit.
+	RequestTimeout time.Duration
+	// Retry controls retries of transient failures.
+	Retry RetryPolicy
+	// AuthMode is AuthSession or AuthBasic.
+	AuthMode string
+	// ExpandQuery is the $expand query used to fetch collections with their
+	// members inline, or "" when the service does not support it.
+	ExpandQuery string
+	// PropertyMap picks the Redfish fields copied into device properties.
+	PropertyMap PropertyMap
+
+	// warnings collects endpoints that could not be read during discovery.
+	warnings []snapshotformat.Warning
+	// session holds the X-Auth-Token when AuthMode is AuthSession.
+	session redfishSession
 }
 
 // --- Redfish Helper Structs ---
 // These are used for unmarshaling Redfish JSON
 
-// SystemInventory holds the discovered devices related to one System/Node.
-// It now holds the canonical DeviceStatus structs.
-type SystemInventory struct {
-	NodeStatus *device.DeviceStatus
-	CPUs       []*device.DeviceStatus
-	DIMMs      []*device.DeviceStatus
+// RedfishServiceRoot holds the service root fields recorded in snapshot
+// provenance, and the query features the walk can use.
+type RedfishServiceRoot struct {
+	RedfishVersion            string `json:"RedfishVersion,omitempty"`
+	UUID                      string `json:"UUID,omitempty"`
+	ProtocolFeaturesSupported struct {
+		ExpandQuery RedfishExpandQuery `json:"ExpandQuery"`
+	} `json:"ProtocolFeaturesSupported"`
+	UpdateService ODataLink `json:"UpdateService"` // Absent on BMCs without one
+}
+
+// RedfishExpandQuery lists the $expand forms a service supports.
+type RedfishExpandQuery struct {
+	ExpandAll bool `json:"ExpandAll"` // $expand=*
+	NoLinks   bool `json:"NoLinks"`   // $expand=.
+	Levels    bool `json:"Levels"`    // ($levels=n)
+}
+
+// ODataLink is a reference to another Redfish resource.
+type ODataLink struct {
+	ODataID string `json:"@odata.id"`
 }
 
 // RedfishCollection defines the structure for Redfish collection responses.
 type RedfishCollection struct {
-	Members []struct {
-		ODataID string `json:"@odata.id"`
-	} `json:"Members"`
+	Members []ODataLink `json:"Members"`
+}
+
+// RedfishExpandedCollection is a collection fetched with $expand, whose
+// members may be whole resources rather than links.
+type RedfishExpandedCollection struct {
+	Members []json.RawMessage `json:"Members"`
 }
 
 // CommonRedfishProperties contains the fields required by the Device model.
@@ -45,21 +87,138 @@ type CommonRedfishProperties struct {
 
 // RedfishSystem defines the structure for a System resource (the Node).
 type RedfishSystem struct {
-	CommonRedfishProperties // Embeds the common fields
-	Processors              struct {
-		ODataID string `json:"@odata.id"`
-	} `json:"Processors"`
-	Memory struct {
-		ODataID string `json:"@odata.id"`
-	} `json:"Memory"`
+	CommonRedfishProperties             // Embeds the common fields
+	Processors              ODataLink   `json:"Processors"`
+	Memory                  ODataLink   `json:"Memory"`
+	Storage                 ODataLink   `json:"Storage"`
+	NetworkInterfaces       ODataLink   `json:"NetworkInterfaces"`
+	PCIeDevices             []ODataLink `json:"PCIeDevices"` // An array of links, not a collection
+	Links                   struct {
+		Chassis []ODataLink `json:"Chassis"` // The chassis the system is in
+	} `json:"Links"`
 }
 
-// RedfishProcessor defines the structure for a Processor resource (the CPU).
+// RedfishProcessor is a member of a system's Processors collection: a CPU,
+// or a GPU, FPGA or other accelerator as told by ProcessorType.
 type RedfishProcessor struct {
-	CommonRedfishProperties // Embeds the common fields
+	CommonRedfishProperties
+	ProcessorType string `json:"ProcessorType"` // CPU, GPU, FPGA, Accelerator, DSP, ...
+	Links         struct {
+		PCIeDevice    ODataLink   `json:"PCIeDevice"`    // The card the processor is on
+		PCIeFunctions []ODataLink `json:"PCIeFunctions"` // Functions it is reached through
+		Memory        []ODataLink `json:"Memory"`        // Memory on the processor, e.g. GPU HBM
+	} `json:"Links"`
 }
 
-// RedfishMemory defines the structure for a Memory resource (the DIMM).
+// RedfishMemory holds the size of a Memory resource.
 type RedfishMemory struct {
-	CommonRedfishProperties // Embeds the common fields
-}
\ No newline at end of file
+	CapacityMiB int `json:"CapacityMiB"`
+}
+
+// RedfishPCIeDevice is a PCIe card, and where it sits.
+type RedfishPCIeDevice struct {
+	CommonRedfishProperties
+	FirmwareVersion string `json:"FirmwareVersion"`
+	Slot            struct {
+		SlotType string `json:"SlotType"`
+		Location struct {
+			PartLocation struct {
+				ServiceLabel string `json:"ServiceLabel"`
+			} `json:"PartLocation"`
+		} `json:"Location"`
+	} `json:"Slot"`
+	PCIeInterface struct {
+		PCIeType   string `json:"PCIeType"`
+		LanesInUse int    `json:"LanesInUse"`
+	} `json:"PCIeInterface"`
+	PCIeFunctions ODataLink `json:"PCIeFunctions"` // A collection since PCIeDevice v1.4
+	Links         struct {
+		PCIeFunctions []ODataLink `json:"PCIeFunctions"` // The older array of links
+	} `json:"Links"`
+}
+
+// RedfishPCIeFunction is one function of a PCIe device.
+type RedfishPCIeFunction struct {
+	VendorID          string `json:"VendorId"`
+	DeviceID          string `json:"DeviceId"`
+	SubsystemVendorID string `json:"SubsystemVendorId"`
+	SubsystemID       string `json:"SubsystemId"`
+	Links             struct {
+		PCIeDevice ODataLink `json:"PCIeDevice"`
+	} `json:"Links"`
+}
+
+// RedfishStorage defines the structure for a Storage subsystem (the StorageController).
+type RedfishStorage struct {
+	CommonRedfishProperties
+	// StorageControllers is the embedded controller list; older services put
+	// the controller's serial number here rather than on the Storage resource.
+	StorageControllers []CommonRedfishProperties `json:"StorageControllers"`
+	Drives             []ODataLink               `json:"Drives"`
+}
+
+// RedfishNetworkInterface is the system-side view of a network adapter.
+type RedfishNetworkInterface struct {
+	Links struct {
+		NetworkAdapter ODataLink `json:"NetworkAdapter"`
+	} `json:"Links"`
+}
+
+// RedfishChassis defines the structure for a Chassis resource.
+type RedfishChassis struct {
+	CommonRedfishProperties
+	ChassisType string `json:"ChassisType"` // e.g. Rack, Enclosure, Blade, RackMount
+	Links       struct {
+		ContainedBy     ODataLink   `json:"ContainedBy"`     // The chassis this one sits in
+		Contains        []ODataLink `json:"Contains"`        // Chassis that sit in this one
+		ComputerSystems []ODataLink `json:"ComputerSystems"` // Systems housed in this chassis
+	} `json:"Links"`
+	NetworkAdapters  ODataLink `json:"NetworkAdapters"`
+	PCIeDevices      ODataLink `json:"PCIeDevices"` // A collection on Chassis, unlike on Systems
+	PowerSubsystem   ODataLink `json:"PowerSubsystem"`
+	ThermalSubsystem ODataLink `json:"ThermalSubsystem"`
+	Power            ODataLink `json:"Power"`   // Deprecated in favour of PowerSubsystem
+	Thermal          ODataLink `json:"Thermal"` // Deprecated in favour of ThermalSubsystem
+}
+
+// RedfishPowerSubsystem links to a chassis' power supplies.
+type RedfishPowerSubsystem struct {
+	PowerSupplies ODataLink `json:"PowerSupplies"`
+}
+
+// RedfishThermalSubsystem links to a chassis' fans.
+type RedfishThermalSubsystem struct {
+	Fans ODataLink `json:"Fans"`
+}
+
+// RedfishEmbeddedMember is an array element of a deprecated Power or Thermal
+// resource. It has an @odata.id but cannot be fetched on its own.
+type RedfishEmbeddedMember struct {
+	ODataID string `json:"@odata.id"`
+	CommonRedfishProperties
+}
+
+// RedfishPower is the deprecated Power resource with embedded power supplies.
+type RedfishPower struct {
+	PowerSupplies []json.RawMessage `json:"PowerSupplies"` // RedfishEmbeddedMember each
+}
+
+// RedfishThermal is the deprecated Thermal resource with embedded fans.
+type RedfishThermal struct {
+	Fans []json.RawMessage `json:"Fans"` // RedfishEmbeddedMember each
+}
+
+// RedfishUpdateService links to the firmware inventory.
+type RedfishUpdateService struct {
+	FirmwareInventory ODataLink `json:"FirmwareInventory"`
+}
+
+// RedfishSoftwareInventory is a member of UpdateService/FirmwareInventory.
+type RedfishSoftwareInventory struct {
+	ID          string      `json:"Id"`
+	Name        string      `json:"Name"`
+	Version     string      `json:"Version"`
+	SoftwareID  string      `json:"SoftwareId"`
+	Updateable  *bool       `json:"Updateable"`  // nil when the service does not say
+	RelatedItem []ODataLink `json:"RelatedItem"` // The resources this firmware runs on
+}
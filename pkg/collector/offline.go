@@ -0,0 +1,153 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	// Import the Fabrica-generated client (the SDK)
+	fabricaclient "github.com/user/inventory-api/pkg/client"
+)
+
+// --- Offline Collection ---
+//
+// A saved snapshot file holds the CreateDiscoverySnapshot request exactly as
+// it would have been posted: {"name": ..., "source": ..., "rawData": ...}.
+// Upload replays it later from a host that can reach the API.
+
+// SaveSnapshot writes a snapshot request under output and returns the file
+// written. If output is a directory, or ends in a path separator, the file is
+// <name>.json inside it; otherwise output is the file name. The file is
+// written to a temporary name first so a crash never leaves half a snapshot.
+func SaveSnapshot(req fabricaclient.CreateDiscoverySnapshotRequest, output string) (string, error) {
+	file := output
+	if info, err := os.Stat(output); (err == nil && info.IsDir()) || strings.HasSuffix(output, string(os.PathSeparator)) {
+		if err := os.MkdirAll(output, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+		file = filepath.Join(output, req.Name+".json")
+	}
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".snapshot-*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return file, nil
+}
+
+// LoadSnapshotFile reads a file written by SaveSnapshot.
+func LoadSnapshotFile(file string) (*fabricaclient.CreateDiscoverySnapshotRequest, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	var req fabricaclient.CreateDiscoverySnapshotRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot file %s: %w", file, err)
+	}
+	if req.Name == "" || len(req.RawData) == 0 {
+		return nil, fmt.Errorf("snapshot file %s has no name or rawData", file)
+	}
+	return &req, nil
+}
+
+// SnapshotFiles expands paths into snapshot files: a directory contributes
+// its *.json files, in name order; a file is taken as is.
+func SnapshotFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// UploadResult is the outcome of uploading one snapshot file.
+type UploadResult struct {
+	File        string
+	Name        string
+	SnapshotUID string
+	Skipped     bool // A snapshot with this name was already on the server
+	Err         error
+}
+
+// Upload posts saved snapshot files with CreateDiscoverySnapshot. It is
+// idempotent: a file whose snapshot name already exists on the server is
+// skipped, so an interrupted upload can simply be run again. A file that fails
+// is reported in its UploadResult and does not stop the others.
+func Upload(ctx context.Context, files []string) ([]UploadResult, error) {
+	sdkClient, err := fabricaclient.NewClient(InventoryAPIHost, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fabrica client: %w", err)
+	}
+	return upload(ctx, sdkClient, files)
+}
+
+// upload posts files through sdkClient; see Upload.
+func upload(ctx context.Context, sdkClient *fabricaclient.Client, files []string) ([]UploadResult, error) {
+	existing, err := sdkClient.GetDiscoverySnapshots(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list existing snapshots: %w", err)
+	}
+	uploaded := make(map[string]string, len(existing))
+	for _, s := range existing {
+		uploaded[s.GetName()] = s.GetUID()
+	}
+
+	results := make([]UploadResult, 0, len(files))
+	for _, file := range files {
+		result := UploadResult{File: file}
+		req, err := LoadSnapshotFile(file)
+		if err != nil {
+			result.Err = err
+			results = append(results, result)
+			continue
+		}
+		result.Name = req.Name
+		if uid, ok := uploaded[req.Name]; ok {
+			result.SnapshotUID, result.Skipped = uid, true
+			results = append(results, result)
+			continue
+		}
+		created, err := sdkClient.CreateDiscoverySnapshot(ctx, *req)
+		if err != nil {
+			result.Err = fmt.Errorf("failed to create snapshot: %w", err)
+		} else {
+			result.SnapshotUID = created.Metadata.UID
+			uploaded[req.Name] = created.Metadata.UID
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
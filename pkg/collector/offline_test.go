@@ -0,0 +1,130 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	fabricaclient "github.com/user/inventory-api/pkg/client"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+)
+
+// fakeAPI lists the snapshots named in existing and records every create.
+type fakeAPI struct {
+	existing []string
+	created  []string
+}
+
+func (a *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		var list []discoverysnapshot.DiscoverySnapshot
+		for _, name := range a.existing {
+			var s discoverysnapshot.DiscoverySnapshot
+			s.Metadata.Name, s.Metadata.UID = name, "uid-"+name
+			list = append(list, s)
+		}
+		json.NewEncoder(w).Encode(list)
+	case http.MethodPost:
+		var req fabricaclient.CreateDiscoverySnapshotRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		a.created = append(a.created, req.Name)
+		a.existing = append(a.existing, req.Name)
+		var s discoverysnapshot.DiscoverySnapshot
+		s.Metadata.Name, s.Metadata.UID = req.Name, "uid-"+req.Name
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(s)
+	}
+}
+
+func saveTestSnapshot(t *testing.T, dir, name string) string {
+	t.Helper()
+	file, err := SaveSnapshot(fabricaclient.CreateDiscoverySnapshotRequest{
+		Name: name,
+		DiscoverySnapshotSpec: discoverysnapshot.DiscoverySnapshotSpec{
+			Source:  "10.0.0.1",
+			RawData: json.RawMessage(`[]`),
+		},
+	}, dir+string(os.PathSeparator))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestSaveAndLoadSnapshot(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out")
+	file := saveTestSnapshot(t, dir, "snapshot-a")
+	if want := filepath.Join(dir, "snapshot-a.json"); file != want {
+		t.Errorf("SaveSnapshot wrote %s, want %s", file, want)
+	}
+	req, err := LoadSnapshotFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Name != "snapshot-a" || req.Source != "10.0.0.1" || string(req.RawData) != "[]" {
+		t.Errorf("LoadSnapshotFile = %+v", req)
+	}
+	temps, _ := filepath.Glob(filepath.Join(dir, ".snapshot-*"))
+	if len(temps) != 0 {
+		t.Errorf("temporary files left behind: %v", temps)
+	}
+}
+
+func TestUploadSkipsExisting(t *testing.T) {
+	dir := t.TempDir()
+	saveTestSnapshot(t, dir, "snapshot-a")
+	saveTestSnapshot(t, dir, "snapshot-b")
+	if err := os.WriteFile(filepath.Join(dir, "snapshot-c.json"), []byte(`{"name": "snapshot-c"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	files, err := SnapshotFiles([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := &fakeAPI{existing: []string{"snapshot-a"}}
+	srv := httptest.NewServer(api)
+	defer srv.Close()
+	sdkClient, err := fabricaclient.NewClient(srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := upload(context.Background(), sdkClient, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	if r := results[0]; !r.Skipped || r.SnapshotUID != "uid-snapshot-a" || r.Err != nil {
+		t.Errorf("snapshot-a result = %+v, want skipped", r)
+	}
+	if r := results[1]; r.Skipped || r.SnapshotUID != "uid-snapshot-b" || r.Err != nil {
+		t.Errorf("snapshot-b result = %+v, want created", r)
+	}
+	if r := results[2]; r.Err == nil {
+		t.Errorf("snapshot-c result = %+v, want an error for the file without rawData", r)
+	}
+
+	// Running again uploads nothing.
+	results, err = upload(context.Background(), sdkClient, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !results[0].Skipped || !results[1].Skipped {
+		t.Errorf("second upload results = %+v, want both skipped", results)
+	}
+	if want := []string{"snapshot-b"}; !reflect.DeepEqual(api.created, want) {
+		t.Errorf("created %v, want %v", api.created, want)
+	}
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	// Import the API's canonical resource definition
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// --- Processors and Accelerators ---
+
+// processorDeviceType maps a Redfish ProcessorType to a device type. GPUs,
+// FPGAs and other accelerators get their own types; CPUs, DSPs and anything
+// unrecognised are CPU.
+func processorDeviceType(processorType string) string {
+	switch processorType {
+	case "GPU":
+		return "GPU"
+	case "FPGA":
+		return "FPGA"
+	case "Accelerator":
+		return "Accelerator"
+	}
+	return "CPU"
+}
+
+// processors adds the members of a system's Processors collection, each
+// classified by its ProcessorType.
+func (w *treeWalker) processors(ctx context.Context, collection ODataLink, systemURI string) {
+	w.eachMember(ctx, collection, func(uri string, body []byte) {
+		var proc RedfishProcessor
+		if err := json.Unmarshal(body, &proc); err != nil {
+			w.c.warnf(uri, "failed to unmarshal processor %s: %v", uri, err)
+			return
+		}
+		deviceType := processorDeviceType(proc.ProcessorType)
+		w.add(proc.CommonRedfishProperties, body, deviceType, uri, systemURI)
+		if status := w.byURI[uri]; status != nil && deviceType != "CPU" {
+			w.accelerator(ctx, status, uri, proc)
+		}
+	})
+}
+
+// accelerator fills in a GPU, FPGA or accelerator from the PCIe device it sits
+// on and from its memory, which many BMCs report there rather than on the
+// processor. The processor links to its device directly, or to a function
+// that does. The device and memory are claimed by the accelerator so they are
+// not reported again as a PCIeDevice or DIMMs.
+func (w *treeWalker) accelerator(ctx context.Context, status *device.DeviceStatus, uri string, proc RedfishProcessor) {
+	prefix := strings.ToLower(status.DeviceType) + "."
+
+	var function *RedfishPCIeFunction
+	if len(proc.Links.PCIeFunctions) > 0 {
+		function = w.pcieFunction(ctx, cleanURI(proc.Links.PCIeFunctions[0].ODataID))
+	}
+	deviceURI := cleanURI(proc.Links.PCIeDevice.ODataID)
+	if deviceURI == "" && function != nil {
+		deviceURI = cleanURI(function.Links.PCIeDevice.ODataID)
+	}
+
+	if deviceURI != "" {
+		var card RedfishPCIeDevice
+		if _, err := w.get(ctx, deviceURI, &card); err != nil {
+			w.c.warnf(deviceURI, "failed to get PCIe device %s of %s: %v", deviceURI, uri, err)
+		} else {
+			w.seen[deviceURI] = true
+			w.mergedInto[deviceURI] = uri
+			if function == nil {
+				function = w.firstPCIeFunction(ctx, card)
+			}
+			// The card often has the serial and part numbers the processor lacks
+			if status.SerialNumber == "" {
+				status.SerialNumber = card.SerialNumber
+			}
+			if status.Manufacturer == "" {
+				status.Manufacturer = card.Manufacturer
+			}
+			// A part number beats the processor's Model, which stood in for one
+			if proc.PartNumber == "" && card.PartNumber != "" {
+				status.PartNumber = card.PartNumber
+			} else if status.PartNumber == "" {
+				status.PartNumber = card.Model
+			}
+			if _, ok := status.Properties[prefix+"firmware_version"]; !ok {
+				snapshotformat.SetStringProperty(status, prefix+"firmware_version", card.FirmwareVersion)
+			}
+			snapshotformat.SetStringProperty(status, "pcie.device_uri", deviceURI)
+			snapshotformat.SetStringProperty(status, "pcie.slot", card.Slot.Location.PartLocation.ServiceLabel)
+			snapshotformat.SetStringProperty(status, "pcie.slot_type", card.Slot.SlotType)
+			snapshotformat.SetStringProperty(status, "pcie.type", card.PCIeInterface.PCIeType)
+			setIntProperty(status, "pcie.lanes", card.PCIeInterface.LanesInUse)
+		}
+	}
+	if function != nil {
+		snapshotformat.SetStringProperty(status, "pcie.vendor_id", function.VendorID)
+		snapshotformat.SetStringProperty(status, "pcie.device_id", function.DeviceID)
+		snapshotformat.SetStringProperty(status, "pcie.subsystem_vendor_id", function.SubsystemVendorID)
+		snapshotformat.SetStringProperty(status, "pcie.subsystem_id", function.SubsystemID)
+	}
+
+	var memoryURIs []string
+	for _, link := range proc.Links.Memory {
+		if memURI := cleanURI(link.ODataID); memURI != "" && !w.seen[memURI] {
+			w.seen[memURI] = true
+			w.mergedInto[memURI] = uri
+			memoryURIs = append(memoryURIs, memURI)
+		}
+	}
+	if _, ok := status.Properties[prefix+"memory_mib"]; ok || len(memoryURIs) == 0 {
+		return
+	}
+	total := 0
+	for _, member := range w.c.GetAll(ctx, memoryURIs) {
+		if member.Err != nil {
+			w.c.warnf(member.URI, "failed to get memory %s of %s: %v", member.URI, uri, member.Err)
+			continue
+		}
+		var memory RedfishMemory
+		if err := json.Unmarshal(member.Body, &memory); err != nil {
+			w.c.warnf(member.URI, "failed to unmarshal memory %s: %v", member.URI, err)
+			continue
+		}
+		total += memory.CapacityMiB
+	}
+	setIntProperty(status, prefix+"memory_mib", total)
+}
+
+// pcieFunction fetches a PCIe function, or returns nil with a warning.
+func (w *treeWalker) pcieFunction(ctx context.Context, uri string) *RedfishPCIeFunction {
+	var function RedfishPCIeFunction
+	if _, err := w.get(ctx, uri, &function); err != nil {
+		w.c.warnf(uri, "failed to get PCIe function %s: %v", uri, err)
+		return nil
+	}
+	return &function
+}
+
+// firstPCIeFunction fetches a device's first function, from its
+// PCIeFunctions collection or the older Links.PCIeFunctions.
+func (w *treeWalker) firstPCIeFunction(ctx context.Context, card RedfishPCIeDevice) *RedfishPCIeFunction {
+	links := card.Links.PCIeFunctions
+	if collection := cleanURI(card.PCIeFunctions.ODataID); collection != "" {
+		uris, err := w.members(ctx, collection)
+		if err != nil {
+			w.c.warnf(collection, "failed to get PCIe functions %s: %v", collection, err)
+			return nil
+		}
+		links = nil
+		for _, uri := range uris {
+			links = append(links, ODataLink{ODataID: uri})
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return w.pcieFunction(ctx, cleanURI(links[0].ODataID))
+}
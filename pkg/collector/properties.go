@@ -0,0 +1,247 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	// Import the API's canonical resource definition
+	"github.com/user/inventory-api/pkg/resources/device"
+	// Import the snapshot payload envelope
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// --- Redfish Property Mapping ---
+
+// PropertyRule copies one Redfish field into a device property.
+type PropertyRule struct {
+	// Field is a dotted path into the Redfish resource, e.g. "Status.Health";
+	// a numeric element indexes an array, e.g. "Controllers.0.FirmwarePackageVersion".
+	Field string `yaml:"field" json:"field"`
+	// Key is the property key, in the README's lowercase snake_case with dots
+	// as namespace separators, e.g. "redfish.status.health".
+	Key string `yaml:"key" json:"key"`
+}
+
+// PropertyMap lists the rules for each device type. Rules under "*" apply to
+// every device. Fields a resource lacks, or that are null or "", are skipped;
+// other values are copied as they are, except that the keys of an object
+// value are converted to snake_case.
+type PropertyMap map[string][]PropertyRule
+
+// AllDeviceTypes is the PropertyMap entry whose rules apply to every device.
+const AllDeviceTypes = "*"
+
+// DefaultPropertyMap is the mapping used unless Options.PropertyMap is set.
+var DefaultPropertyMap = PropertyMap{
+	AllDeviceTypes: {
+		{Field: "Status.Health", Key: "redfish.status.health"},
+		{Field: "Status.State", Key: "redfish.status.state"},
+		{Field: "SKU", Key: "redfish.sku"},
+		{Field: "AssetTag", Key: "redfish.asset_tag"},
+		{Field: "UUID", Key: "redfish.uuid"},
+		{Field: "Model", Key: "redfish.model"},
+		{Field: "Location.PartLocation.ServiceLabel", Key: "redfish.location.service_label"},
+	},
+	"Node": {
+		{Field: "BiosVersion", Key: "bios.version"},
+		{Field: "SystemType", Key: "system.type"},
+		{Field: "ProcessorSummary.Count", Key: "system.processor_count"},
+		{Field: "MemorySummary.TotalSystemMemoryGiB", Key: "system.memory_gib"},
+	},
+	"CPU": {
+		{Field: "ProcessorType", Key: "processor.type"},
+		{Field: "ProcessorArchitecture", Key: "processor.architecture"},
+		{Field: "InstructionSet", Key: "processor.instruction_set"},
+		{Field: "TotalCores", Key: "processor.total_cores"},
+		{Field: "TotalThreads", Key: "processor.total_threads"},
+		{Field: "MaxSpeedMHz", Key: "processor.max_speed_mhz"},
+		{Field: "Socket", Key: "processor.socket"},
+	},
+	"DIMM": {
+		{Field: "CapacityMiB", Key: "memory.capacity_mib"},
+		{Field: "MemoryDeviceType", Key: "memory.device_type"},
+		{Field: "OperatingSpeedMhz", Key: "memory.operating_speed_mhz"},
+		{Field: "DeviceLocator", Key: "memory.device_locator"},
+	},
+	"Disk": {
+		{Field: "CapacityBytes", Key: "drive.capacity_bytes"},
+		{Field: "MediaType", Key: "drive.media_type"},
+		{Field: "Protocol", Key: "drive.protocol"},
+		{Field: "Revision", Key: "drive.revision"},
+	},
+	"NIC": {
+		{Field: "Controllers.0.FirmwarePackageVersion", Key: "nic.firmware_version"},
+	},
+	"GPU": {
+		{Field: "FirmwareVersion", Key: "gpu.firmware_version"},
+		{Field: "MemorySummary.TotalMemorySizeMiB", Key: "gpu.memory_mib"},
+	},
+	"FPGA": {
+		{Field: "FirmwareVersion", Key: "fpga.firmware_version"},
+		{Field: "MemorySummary.TotalMemorySizeMiB", Key: "fpga.memory_mib"},
+	},
+	"Accelerator": {
+		{Field: "FirmwareVersion", Key: "accelerator.firmware_version"},
+		{Field: "MemorySummary.TotalMemorySizeMiB", Key: "accelerator.memory_mib"},
+	},
+	"PowerSupply": {
+		{Field: "PowerCapacityWatts", Key: "power_supply.capacity_watts"},
+		{Field: "FirmwareVersion", Key: "power_supply.firmware_version"},
+	},
+	"BMC": {
+		{Field: "FirmwareVersion", Key: "manager.firmware_version"},
+		{Field: "ManagerType", Key: "manager.type"},
+	},
+}
+
+// propertyKeyPattern is the README's rule for property keys.
+var propertyKeyPattern = regexp.MustCompile(`^[a-z0-9_]+(\.[a-z0-9_]+)*$`)
+
+// reservedPropertyKeys are set by the collector itself; the reconciler relies on them.
+var reservedPropertyKeys = map[string]bool{
+	"redfish_uri":        true,
+	"redfish_parent_uri": true,
+	"chassis_type":       true,
+}
+
+// propertyMapFile is the layout of a --property-map file. YAML and JSON both parse.
+//
+//	replace: false        # true drops DefaultPropertyMap instead of adding to it
+//	properties:
+//	  "*":
+//	    - field: Oem.Contoso.RackUnit
+//	      key: contoso.rack_unit
+//	  DIMM:
+//	    - field: RankCount
+//	      key: memory.rank_count
+type propertyMapFile struct {
+	Replace    bool        `yaml:"replace"`
+	Properties PropertyMap `yaml:"properties"`
+}
+
+// LoadPropertyMap reads a mapping file and returns DefaultPropertyMap with
+// its rules added, or just its rules if it sets replace. A rule whose key a
+// default rule for the same device type already uses takes that rule's place.
+func LoadPropertyMap(filename string) (PropertyMap, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read property map: %w", err)
+	}
+	var file propertyMapFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse property map %s: %w", filename, err)
+	}
+	if err := file.Properties.Validate(); err != nil {
+		return nil, fmt.Errorf("property map %s: %w", filename, err)
+	}
+	if file.Replace {
+		return file.Properties, nil
+	}
+	return DefaultPropertyMap.Merge(file.Properties), nil
+}
+
+// Validate checks every rule has a field and a well-formed key.
+func (m PropertyMap) Validate() error {
+	for deviceType, rules := range m {
+		for i, rule := range rules {
+			switch {
+			case rule.Field == "":
+				return fmt.Errorf("%s rule %d has no field", deviceType, i+1)
+			case !propertyKeyPattern.MatchString(rule.Key):
+				return fmt.Errorf("%s rule %d: key %q must be lowercase snake_case, dot-separated", deviceType, i+1, rule.Key)
+			case reservedPropertyKeys[rule.Key]:
+				return fmt.Errorf("%s rule %d: key %q is set by the collector", deviceType, i+1, rule.Key)
+			}
+		}
+	}
+	return nil
+}
+
+// Merge returns a copy of m with the rules of other added.
+func (m PropertyMap) Merge(other PropertyMap) PropertyMap {
+	merged := make(PropertyMap, len(m)+len(other))
+	for deviceType, rules := range m {
+		merged[deviceType] = append([]PropertyRule(nil), rules...)
+	}
+	for deviceType, rules := range other {
+		for _, rule := range rules {
+			existing := merged[deviceType]
+			replaced := false
+			for i := range existing {
+				if existing[i].Key == rule.Key {
+					existing[i], replaced = rule, true
+				}
+			}
+			if !replaced {
+				merged[deviceType] = append(existing, rule)
+			}
+		}
+	}
+	return merged
+}
+
+// apply copies the mapped fields of a resource body into status.Properties.
+// Rules for the device type are applied after the "*" rules, so they win
+// when both set the same key.
+func (m PropertyMap) apply(status *device.DeviceStatus, body []byte) {
+	if len(m) == 0 || len(body) == 0 {
+		return
+	}
+	resource, err := decodeNumbers(body)
+	if err != nil {
+		return
+	}
+	for _, rules := range [][]PropertyRule{m[AllDeviceTypes], m[status.DeviceType]} {
+		for _, rule := range rules {
+			value, ok := lookupField(resource, rule.Field)
+			if !ok || value == nil || value == "" {
+				continue
+			}
+			raw, err := json.Marshal(snapshotformat.SnakeKeys(value))
+			if err != nil {
+				continue
+			}
+			status.Properties[rule.Key] = raw
+		}
+	}
+}
+
+// lookupField follows a dotted path through decoded JSON.
+func lookupField(v interface{}, field string) (interface{}, bool) {
+	for _, part := range strings.Split(field, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			next, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// decodeNumbers keeps JSON numbers as written, so large integers such as
+// CapacityBytes survive the round trip.
+func decodeNumbers(body []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
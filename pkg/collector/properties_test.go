@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+func writePropertyMap(t *testing.T, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "properties.yaml")
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPropertyMap(t *testing.T) {
+	path := writePropertyMap(t, `properties:
+  DIMM:
+    - field: RankCount
+      key: memory.rank_count
+    - field: Oem.Contoso.CapacityMiB
+      key: memory.capacity_mib
+`)
+	m, err := LoadPropertyMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(m[AllDeviceTypes], DefaultPropertyMap[AllDeviceTypes]) || !reflect.DeepEqual(m["CPU"], DefaultPropertyMap["CPU"]) {
+		t.Error("default rules for other device types were not kept")
+	}
+	want := []PropertyRule{
+		{Field: "Oem.Contoso.CapacityMiB", Key: "memory.capacity_mib"}, // Overrides the default rule in place
+		{Field: "MemoryDeviceType", Key: "memory.device_type"},
+		{Field: "OperatingSpeedMhz", Key: "memory.operating_speed_mhz"},
+		{Field: "DeviceLocator", Key: "memory.device_locator"},
+		{Field: "RankCount", Key: "memory.rank_count"},
+	}
+	if !reflect.DeepEqual(m["DIMM"], want) {
+		t.Errorf("DIMM rules = %+v, want %+v", m["DIMM"], want)
+	}
+	if DefaultPropertyMap["DIMM"][0].Field != "CapacityMiB" {
+		t.Error("Merge modified DefaultPropertyMap")
+	}
+
+	path = writePropertyMap(t, `replace: true
+properties:
+  "*":
+    - field: Oem.Contoso.RackUnit
+      key: contoso.rack_unit
+`)
+	m, err = LoadPropertyMap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (PropertyMap{AllDeviceTypes: {{Field: "Oem.Contoso.RackUnit", Key: "contoso.rack_unit"}}}); !reflect.DeepEqual(m, want) {
+		t.Errorf("replaced map = %+v, want %+v", m, want)
+	}
+}
+
+func TestLoadPropertyMapRejectsBadKeys(t *testing.T) {
+	tests := []struct {
+		rule string
+		want string
+	}{
+		{"field: Id\n      key: redfish_uri", "set by the collector"},
+		{"field: Id\n      key: chassis_type", "set by the collector"},
+		{"field: Id\n      key: RackUnit", "snake_case"},
+		{"field: Id\n      key: rack-unit", "snake_case"},
+		{"field: Id\n      key: contoso..rack_unit", "snake_case"},
+		{"field: Id\n      key: \"\"", "snake_case"},
+		{"key: contoso.id", "has no field"},
+	}
+	for _, tt := range tests {
+		path := writePropertyMap(t, "properties:\n  Node:\n    - "+tt.rule+"\n")
+		if _, err := LoadPropertyMap(path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("LoadPropertyMap(%q) error = %v, want %q", tt.rule, err, tt.want)
+		}
+	}
+}
+
+func TestPropertyMapApply(t *testing.T) {
+	m := PropertyMap{
+		AllDeviceTypes: {
+			{Field: "Status.Health", Key: "redfish.status.health"},
+			{Field: "Model", Key: "model"},
+		},
+		"Disk": {
+			{Field: "CapacityBytes", Key: "drive.capacity_bytes"},
+			{Field: "Identifiers", Key: "drive.identifiers"},
+			{Field: "Identifiers.0.DurableNameFormat", Key: "drive.name_format"},
+			{Field: "Revision", Key: "drive.revision"},
+			{Field: "AssetTag", Key: "drive.asset_tag"},
+			{Field: "PartNumber", Key: "model"}, // Device type rules win
+		},
+	}
+	body := []byte(`{
+		"Status": {"Health": "OK"},
+		"Model": "X1",
+		"PartNumber": "P-9",
+		"CapacityBytes": 18000207937536,
+		"Identifiers": [{"DurableName": "5000C500", "DurableNameFormat": "NAA"}],
+		"Revision": "",
+		"AssetTag": null
+	}`)
+	status := &device.DeviceStatus{DeviceType: "Disk", Properties: map[string]json.RawMessage{}}
+	m.apply(status, body)
+
+	want := map[string]string{
+		"redfish.status.health": `"OK"`,
+		"model":                 `"P-9"`,
+		"drive.capacity_bytes":  `18000207937536`,
+		"drive.identifiers":     `[{"durable_name":"5000C500","durable_name_format":"NAA"}]`,
+		"drive.name_format":     `"NAA"`,
+	}
+	got := make(map[string]string)
+	for key, raw := range status.Properties {
+		got[key] = string(raw)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("properties = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,218 @@
+package collector
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/user/inventory-api/pkg/redfishmock"
+)
+
+// --- Record and Replay ---
+//
+// A recording is one directory per BMC in the DMTF mockup layout that
+// pkg/redfishmock serves: the body of every GET that returned 200 is saved as
+// <dir>/<address>/redfish/v1/<uri>/index.json, exactly as the BMC sent it.
+// Other statuses go to faults.json beside the redfish directory, with a count
+// when the URI later succeeded on retry. A recording can be replayed with
+// Options.Replay, or served with cmd/redfishmock.
+
+// recordingTransport saves Redfish GET responses as they pass through.
+type recordingTransport struct {
+	next   http.RoundTripper
+	client *RedfishClient // For logging
+	dir    string
+
+	mu     sync.Mutex
+	faults map[string]*recordedFault
+	err    error // First write error, reported once
+}
+
+// recordedFault tracks the error responses for one URI.
+type recordedFault struct {
+	status    int
+	failures  int
+	recovered bool // A later request succeeded
+}
+
+// recordTo makes c save its responses under dir/<c.Address>.
+func (c *RedfishClient) recordTo(dir string) error {
+	dir = filepath.Join(dir, recordingName(c.Address))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	c.HTTPClient.Transport = &recordingTransport{
+		next:   c.HTTPClient.Transport,
+		client: c,
+		dir:    dir,
+		faults: make(map[string]*recordedFault),
+	}
+	return nil
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method != http.MethodGet {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// A rejected login or expired session says nothing about the BMC's data,
+	// and would fail every replay.
+	if resp.StatusCode != http.StatusUnauthorized {
+		if err := t.save(req.URL.Path, resp.StatusCode, body); err != nil {
+			t.mu.Lock()
+			if t.err == nil {
+				t.err = err
+				t.client.logf("Warning: recording is incomplete: %v", err)
+			}
+			t.mu.Unlock()
+		}
+	}
+	return resp, nil
+}
+
+// save writes one response. $expand and other queries are not part of the
+// file name, so an expanded collection is saved as the collection itself.
+func (t *recordingTransport) save(uri string, status int, body []byte) error {
+	uri = path.Clean("/" + uri)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if status != http.StatusOK {
+		f := t.faults[uri]
+		if f == nil {
+			f = &recordedFault{}
+			t.faults[uri] = f
+		}
+		f.status = status
+		f.failures++
+		return t.writeFaults()
+	}
+
+	file := filepath.Join(t.dir, filepath.FromSlash(uri), "index.json")
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(file, body, 0o644); err != nil {
+		return err
+	}
+	if f := t.faults[uri]; f != nil && !f.recovered {
+		f.recovered = true
+		return t.writeFaults()
+	}
+	return nil
+}
+
+// writeFaults rewrites faults.json, so an interrupted run still leaves a
+// usable recording.
+func (t *recordingTransport) writeFaults() error {
+	faults := make([]redfishmock.Fault, 0, len(t.faults))
+	for uri, f := range t.faults {
+		fault := redfishmock.Fault{Path: uri, Status: f.status}
+		if f.recovered {
+			fault.Count = f.failures
+		}
+		faults = append(faults, fault)
+	}
+	sort.Slice(faults, func(i, j int) bool { return faults[i].Path < faults[j].Path })
+	data, err := json.MarshalIndent(map[string]interface{}{"faults": faults}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(t.dir, "faults.json"), data, 0o644)
+}
+
+// recordingName is the directory a BMC's recording is kept in: its address,
+// with any scheme and path separators made safe for a file name.
+func recordingName(address string) string {
+	return strings.NewReplacer("://", "_", "/", "_", `\`, "_").Replace(address)
+}
+
+// recordingDir is where the recording of a target address lives under dir.
+// The address is normalised the way NewRedfishClient does it, so
+// "http://host:8000" finds the recording saved for host:8000.
+func recordingDir(dir, address string) (string, error) {
+	_, host, err := splitScheme(address)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, recordingName(host)), nil
+}
+
+// NewReplayClient returns a client that reads the recording in dir instead
+// of a BMC. Its Address is the name of dir, i.e. the recorded BMC's address,
+// so a replayed snapshot reconciles against that BMC's devices.
+func NewReplayClient(dir string) (*RedfishClient, error) {
+	h, err := redfishmock.NewHandler(os.DirFS(dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load recording %s: %w", dir, err)
+	}
+	return &RedfishClient{
+		Address: filepath.Base(dir),
+		BaseURL: "http://replay/redfish/v1",
+		// The mock handler wants some user name; any will do.
+		Username:       "replay",
+		HTTPClient:     &http.Client{Transport: replayTransport{h}},
+		MaxConcurrency: DefaultMaxConcurrency,
+		AuthMode:       AuthBasic,
+		RequestTimeout: DefaultRequestTimeout,
+		Retry:          DefaultRetryPolicy,
+		PropertyMap:    DefaultPropertyMap,
+	}, nil
+}
+
+// replayTransport answers requests from a recording, in process.
+type replayTransport struct {
+	handler http.Handler
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	resp := rec.Result()
+	resp.Request = req
+	return resp, nil
+}
+
+// ReplayTargets lists the recordings under dir and returns the directory
+// holding them, for Options.Replay. dir may be one recording, or a --record
+// directory holding one per BMC.
+func ReplayTargets(dir string) (string, []Target, error) {
+	if isRecording(dir) {
+		dir = filepath.Clean(dir)
+		return filepath.Dir(dir), []Target{{Address: filepath.Base(dir)}}, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read replay directory: %w", err)
+	}
+	var targets []Target
+	for _, e := range entries {
+		if e.IsDir() && isRecording(filepath.Join(dir, e.Name())) {
+			targets = append(targets, Target{Address: e.Name()})
+		}
+	}
+	if len(targets) == 0 {
+		return "", nil, fmt.Errorf("no recordings in %s", dir)
+	}
+	return dir, targets, nil
+}
+
+func isRecording(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, "redfish", "v1", "index.json"))
+	return err == nil
+}
@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/inventory-api/pkg/redfishmock"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// savedDevices reads the device list out of a snapshot file written by
+// collectTarget with Options.Output.
+func savedDevices(t *testing.T, file string) json.RawMessage {
+	t.Helper()
+	req, err := LoadSnapshotFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var env snapshotformat.Envelope
+	if err := json.Unmarshal(req.RawData, &env); err != nil {
+		t.Fatal(err)
+	}
+	return env.Data
+}
+
+func TestRecordReplay(t *testing.T) {
+	srv, err := redfishmock.NewServer(bundledMockup(t, "server-errors"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+
+	dir := t.TempDir()
+	recordings := filepath.Join(dir, "recordings")
+	live := collectTarget(context.Background(), nil,
+		Target{Address: srv.Address(), Username: "admin", Password: "secret"},
+		Options{
+			TLS:    TLSOptions{RootCAs: pool},
+			Retry:  RetryPolicy{MaxAttempts: 3},
+			Record: recordings,
+			Output: filepath.Join(dir, "live") + string(os.PathSeparator),
+		})
+	if live.Err != nil {
+		t.Fatal(live.Err)
+	}
+
+	replayDir, targets, err := ReplayTargets(recordings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].Address != srv.Address() {
+		t.Fatalf("ReplayTargets = %+v, want the recorded BMC", targets)
+	}
+	replayed := collectTarget(context.Background(), nil, targets[0], Options{
+		Retry:  RetryPolicy{MaxAttempts: 3},
+		Replay: replayDir,
+		Output: filepath.Join(dir, "replay") + string(os.PathSeparator),
+	})
+	if replayed.Err != nil {
+		t.Fatal(replayed.Err)
+	}
+
+	if replayed.Devices != live.Devices || replayed.Warnings != live.Warnings {
+		t.Errorf("replay found %d devices and %d warnings, live found %d and %d",
+			replayed.Devices, replayed.Warnings, live.Devices, live.Warnings)
+	}
+	if got, want := savedDevices(t, replayed.File), savedDevices(t, live.File); !bytes.Equal(got, want) {
+		t.Errorf("replayed devices differ from the live walk:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestReplayHTTPAddress(t *testing.T) {
+	srv, err := redfishmock.NewUnstartedServer(bundledMockup(t, "single-node"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.Start()
+	defer srv.Close()
+
+	// The recording is saved under host:port; replaying the same target, with
+	// its scheme, must find it there.
+	target := Target{Address: "http://" + srv.Address(), Username: "admin", Password: "secret"}
+	dir := t.TempDir()
+	recordings := filepath.Join(dir, "recordings")
+	live := collectTarget(context.Background(), nil, target, Options{
+		Record: recordings,
+		Output: filepath.Join(dir, "live") + string(os.PathSeparator),
+	})
+	if live.Err != nil {
+		t.Fatal(live.Err)
+	}
+	if _, err := os.Stat(filepath.Join(recordings, recordingName(srv.Address()), "redfish", "v1", "index.json")); err != nil {
+		t.Fatalf("recording not saved under the BMC's host:port: %v", err)
+	}
+
+	replayed := collectTarget(context.Background(), nil, target, Options{
+		Replay: recordings,
+		Output: filepath.Join(dir, "replay") + string(os.PathSeparator),
+	})
+	if replayed.Err != nil {
+		t.Fatal(replayed.Err)
+	}
+	if got, want := savedDevices(t, replayed.File), savedDevices(t, live.File); !bytes.Equal(got, want) {
+		t.Errorf("replayed devices differ from the live walk:\n got %s\nwant %s", got, want)
+	}
+}
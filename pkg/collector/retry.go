@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// --- Request Deadlines and Retries ---
+
+// DefaultRequestTimeout bounds a single Redfish request, including reading the body.
+const DefaultRequestTimeout = 30 * time.Second
+
+// DefaultRetryPolicy retries a failed request three times, backing off from
+// half a second up to ten seconds.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, BaseDelay: 500 * time.Millisecond, MaxDelay: 10 * time.Second}
+
+// RetryPolicy controls how RedfishClient retries transient failures: 5xx and
+// 429 responses, connection resets, and requests that hit their own timeout.
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts per request, including the first; 1 disables retries
+	BaseDelay   time.Duration // Backoff before the second attempt, doubling after each retry
+	MaxDelay    time.Duration // Upper bound on the backoff and on a Retry-After wait
+}
+
+// backoff returns the jittered wait before retry number n (1-based): a random
+// duration between half and all of BaseDelay*2^(n-1), capped at MaxDelay.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// statusError is an unexpected HTTP status from the BMC.
+type statusError struct {
+	Code int
+	URL  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("Redfish API returned status code %d for %s", e.Code, e.URL)
+}
+
+// retryableStatus reports whether a response status is worth retrying.
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+// retryableError reports whether an error is worth retrying. The caller's own
+// context ending is not; a request timing out on its own is.
+func retryableError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return retryableStatus(se.Code)
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date.
+// It returns 0 if the header is absent or unreadable.
+func retryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// retryAfterLimit returns the longest Retry-After wait worth honouring: MaxDelay,
+// or less if ctx ends sooner. A negative result means no limit.
+func (p RetryPolicy) retryAfterLimit(ctx context.Context, now time.Time) time.Duration {
+	limit := time.Duration(-1)
+	if p.MaxDelay > 0 {
+		limit = p.MaxDelay
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		if left := deadline.Sub(now); limit < 0 || left < limit {
+			limit = max(left, 0)
+		}
+	}
+	return limit
+}
+
+// sleep waits for d or until ctx ends, whichever is first.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,223 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyBMC answers the first len(failures) requests with those statuses and
+// then succeeds.
+type flakyBMC struct {
+	failures   []int
+	retryAfter string
+	requests   atomic.Int32
+}
+
+func (b *flakyBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := int(b.requests.Add(1))
+	if n <= len(b.failures) {
+		if b.retryAfter != "" {
+			w.Header().Set("Retry-After", b.retryAfter)
+		}
+		w.WriteHeader(b.failures[n-1])
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func newFlakyClient(t *testing.T, bmc *flakyBMC) *RedfishClient {
+	t.Helper()
+	srv := httptest.NewServer(bmc)
+	t.Cleanup(srv.Close)
+	c, err := NewRedfishClient(context.Background(), srv.URL, testLogin, TLSOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AuthMode = AuthBasic
+	c.Retry = RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+	return c
+}
+
+func TestGetRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures []int
+		wantErr  bool
+		requests int32
+	}{
+		{"success", nil, false, 1},
+		{"transient failures", []int{http.StatusServiceUnavailable, http.StatusBadGateway}, false, 3},
+		{"too many requests", []int{http.StatusTooManyRequests}, false, 2},
+		{"attempts exhausted", []int{500, 500, 500, 500}, true, 3},
+		{"not found is not retried", []int{http.StatusNotFound}, true, 1},
+		{"forbidden is not retried", []int{http.StatusForbidden}, true, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmc := &flakyBMC{failures: tt.failures}
+			c := newFlakyClient(t, bmc)
+			_, err := c.Get(context.Background(), "/")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Get error = %v, want error %v", err, tt.wantErr)
+			}
+			if got := bmc.requests.Load(); got != tt.requests {
+				t.Errorf("made %d requests, want %d", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestGetHonoursRetryAfter(t *testing.T) {
+	bmc := &flakyBMC{failures: []int{http.StatusServiceUnavailable}, retryAfter: "1"}
+	c := newFlakyClient(t, bmc)
+	c.Retry.MaxDelay = 5 * time.Second
+	start := time.Now()
+	if _, err := c.Get(context.Background(), "/"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want at least the 1s Retry-After", elapsed)
+	}
+}
+
+func TestGetRefusesLongRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxDelay time.Duration
+		timeout  time.Duration // Zero means no deadline
+	}{
+		{"longer than MaxDelay", 10 * time.Millisecond, 0},
+		{"longer than the deadline", time.Hour, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bmc := &flakyBMC{failures: []int{http.StatusServiceUnavailable}, retryAfter: "60"}
+			c := newFlakyClient(t, bmc)
+			c.Retry.MaxDelay = tt.maxDelay
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			_, err := c.Get(ctx, "/")
+			var se *statusError
+			if !errors.As(err, &se) || se.Code != http.StatusServiceUnavailable {
+				t.Errorf("Get error = %v, want the 503", err)
+			}
+			if got := bmc.requests.Load(); got != 1 {
+				t.Errorf("made %d requests, want 1", got)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Errorf("gave up after %s, want at once", elapsed)
+			}
+		})
+	}
+}
+
+func TestRetryAfterLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	background := context.Background()
+	soon, cancel := context.WithDeadline(background, now.Add(3*time.Second))
+	defer cancel()
+	later, cancel := context.WithDeadline(background, now.Add(time.Hour))
+	defer cancel()
+	past, cancel := context.WithDeadline(background, now.Add(-time.Second))
+	defer cancel()
+	tests := []struct {
+		name     string
+		maxDelay time.Duration
+		ctx      context.Context
+		want     time.Duration
+	}{
+		{"MaxDelay", 10 * time.Second, background, 10 * time.Second},
+		{"deadline sooner", 10 * time.Second, soon, 3 * time.Second},
+		{"deadline later", 10 * time.Second, later, 10 * time.Second},
+		{"deadline only", 0, soon, 3 * time.Second},
+		{"deadline passed", 10 * time.Second, past, 0},
+		{"no limit", 0, background, -1},
+	}
+	for _, tt := range tests {
+		p := RetryPolicy{MaxDelay: tt.maxDelay}
+		if got := p.retryAfterLimit(tt.ctx, now); got != tt.want {
+			t.Errorf("%s: retryAfterLimit = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGetStopsWhenContextEnds(t *testing.T) {
+	bmc := &flakyBMC{failures: []int{500, 500, 500}}
+	c := newFlakyClient(t, bmc)
+	c.Retry.BaseDelay, c.Retry.MaxDelay = time.Hour, time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := c.Get(ctx, "/")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Get error = %v, want the context deadline", err)
+	}
+	if got := bmc.requests.Load(); got != 1 {
+		t.Errorf("made %d requests, want 1", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{"", 0},
+		{"5", 5 * time.Second},
+		{"0", 0},
+		{"-3", 0},
+		{"soon", 0},
+		{now.Add(90 * time.Second).Format(http.TimeFormat), 90 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+	}
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, now); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 6, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for n, max := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		max *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(n + 1); d < max/2 || d > max {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", n+1, d, max/2, max)
+			}
+		}
+	}
+}
+
+func TestRetryableError(t *testing.T) {
+	ctx := context.Background()
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	tests := []struct {
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{ctx, &statusError{Code: 503}, true},
+		{ctx, &statusError{Code: 429}, true},
+		{ctx, &statusError{Code: 404}, false},
+		{ctx, context.DeadlineExceeded, true},
+		{ctx, errors.New("x509: certificate signed by unknown authority"), false},
+		{canceled, &statusError{Code: 503}, false},
+	}
+	for _, tt := range tests {
+		if got := retryableError(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("retryableError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
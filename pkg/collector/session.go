@@ -0,0 +1,158 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// --- Redfish Sessions ---
+
+// Authentication modes for RedfishClient.AuthMode.
+const (
+	AuthSession = "session" // Log in once through SessionService and send X-Auth-Token
+	AuthBasic   = "basic"   // Send HTTP basic auth on every request
+)
+
+// sessionsPath is the session collection every Redfish service exposes at a fixed URI.
+const sessionsPath = "/SessionService/Sessions"
+
+// sessionCloseTimeout bounds the logout at the end of a walk. It does not use
+// the collection context, which may already have expired.
+const sessionCloseTimeout = 10 * time.Second
+
+// redfishSession is the client's login state. One session is shared by all
+// concurrent requests to the BMC.
+type redfishSession struct {
+	mu       sync.Mutex
+	token    string // X-Auth-Token
+	location string // Session resource URL, deleted on Close
+	basic    bool   // The service has no SessionService; basic auth is used instead
+}
+
+// authorize adds credentials to req, logging in first if there is no session
+// yet. It returns the session token used, or "" for basic auth.
+func (c *RedfishClient) authorize(req *http.Request) (string, error) {
+	if c.AuthMode != AuthBasic {
+		c.session.mu.Lock()
+		defer c.session.mu.Unlock()
+		if c.session.token == "" && !c.session.basic {
+			if err := c.login(req.Context()); err != nil {
+				return "", err
+			}
+		}
+		if !c.session.basic {
+			req.Header.Set("X-Auth-Token", c.session.token)
+			return c.session.token, nil
+		}
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+	return "", nil
+}
+
+// expireSession forgets token after the BMC rejected it, so the next request
+// logs in again. A token already replaced by another request is left alone.
+func (c *RedfishClient) expireSession(token string) {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	if c.session.token == token {
+		c.session.token = ""
+		c.session.location = ""
+	}
+}
+
+// login creates a session. Services without a SessionService fall back to
+// basic auth. The caller holds c.session.mu.
+func (c *RedfishClient) login(ctx context.Context) error {
+	targetURL, err := url.JoinPath(c.BaseURL, sessionsPath)
+	if err != nil {
+		return fmt.Errorf("failed to join path: %w", err)
+	}
+	payload, err := json.Marshal(map[string]string{"UserName": c.Username, "Password": c.Password})
+	if err != nil {
+		return fmt.Errorf("failed to marshal session request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, targetURL, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create session request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to create Redfish session: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		c.logf("SessionService is not available (status %d), using basic auth", resp.StatusCode)
+		c.session.basic = true
+		return nil
+	default:
+		return fmt.Errorf("failed to create Redfish session: %w", &statusError{Code: resp.StatusCode, URL: targetURL})
+	}
+
+	token := resp.Header.Get("X-Auth-Token")
+	if token == "" {
+		return fmt.Errorf("failed to create Redfish session: no X-Auth-Token in response from %s", targetURL)
+	}
+	location := resp.Header.Get("Location")
+	if location == "" {
+		var link ODataLink
+		if json.Unmarshal(body, &link) == nil {
+			location = link.ODataID
+		}
+	}
+	if location != "" {
+		base, err := url.Parse(c.BaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to parse base URL: %w", err)
+		}
+		ref, err := url.Parse(location)
+		if err != nil {
+			return fmt.Errorf("invalid session location %q: %w", location, err)
+		}
+		location = base.ResolveReference(ref).String()
+	}
+	c.session.token = token
+	c.session.location = location
+	return nil
+}
+
+// Close deletes the client's session, if it has one. It is safe to call more
+// than once, and does nothing under basic auth.
+func (c *RedfishClient) Close() error {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	token, location := c.session.token, c.session.location
+	c.session.token, c.session.location = "", ""
+	if token == "" || location == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sessionCloseTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, location, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create session delete request: %w", err)
+	}
+	req.Header.Set("X-Auth-Token", token)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to delete Redfish session %s: %w", location, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("failed to delete Redfish session %s: status code %d", location, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sessionBMC is a Redfish service that issues session tokens. Requests with
+// a live token or valid basic auth get a small service root; everything else
+// under /redfish/v1 is 404.
+type sessionBMC struct {
+	mu sync.Mutex
+	// loginStatus, if set, is returned instead of creating a session.
+	loginStatus int
+	logins      int
+	deleted     []string
+	basicAuth   int
+	live        map[string]bool
+}
+
+func (b *sessionBMC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/redfish/v1/SessionService/Sessions":
+		if b.loginStatus != 0 {
+			w.WriteHeader(b.loginStatus)
+			return
+		}
+		b.logins++
+		token := fmt.Sprintf("token-%d", b.logins)
+		b.live[token] = true
+		w.Header().Set("X-Auth-Token", token)
+		w.Header().Set("Location", "/redfish/v1/SessionService/Sessions/"+token)
+		w.WriteHeader(http.StatusCreated)
+		return
+	case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/redfish/v1/SessionService/Sessions/"):
+		b.deleted = append(b.deleted, strings.TrimPrefix(r.URL.Path, "/redfish/v1/SessionService/Sessions/"))
+		delete(b.live, r.Header.Get("X-Auth-Token"))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if user, pass, ok := r.BasicAuth(); ok && user == "root" && pass == "secret" {
+		b.basicAuth++
+	} else if !b.live[r.Header.Get("X-Auth-Token")] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if r.URL.Path != "/redfish/v1" && r.URL.Path != "/redfish/v1/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Write([]byte(`{"RedfishVersion": "1.6.0"}`))
+}
+
+// expire invalidates every issued token, as a BMC does when sessions time out.
+func (b *sessionBMC) expire() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.live = map[string]bool{}
+}
+
+func newSessionBMC(t *testing.T, loginStatus int) (*sessionBMC, string) {
+	t.Helper()
+	bmc := &sessionBMC{loginStatus: loginStatus, live: map[string]bool{}}
+	srv := httptest.NewTLSServer(bmc)
+	t.Cleanup(srv.Close)
+	return bmc, strings.TrimPrefix(srv.URL, "https://")
+}
+
+func TestSessionLogin(t *testing.T) {
+	bmc, addr := newSessionBMC(t, 0)
+	c, _ := NewRedfishClient(context.Background(), addr, testLogin, TLSOptions{Insecure: true})
+	for i := 0; i < 3; i++ {
+		if _, err := c.Get(context.Background(), "/"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if bmc.logins != 1 || bmc.basicAuth != 0 {
+		t.Errorf("logins = %d, basic auth requests = %d, want 1 login and no basic auth", bmc.logins, bmc.basicAuth)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(bmc.deleted) != 1 || bmc.deleted[0] != "token-1" {
+		t.Errorf("deleted sessions = %v, want [token-1]", bmc.deleted)
+	}
+}
+
+func TestSessionExpired(t *testing.T) {
+	bmc, addr := newSessionBMC(t, 0)
+	c, _ := NewRedfishClient(context.Background(), addr, testLogin, TLSOptions{Insecure: true})
+	if _, err := c.Get(context.Background(), "/"); err != nil {
+		t.Fatal(err)
+	}
+	bmc.expire()
+	if _, err := c.Get(context.Background(), "/"); err != nil {
+		t.Fatalf("Get after expiry: %v", err)
+	}
+	if bmc.logins != 2 {
+		t.Errorf("logins = %d, want a second login after the 401", bmc.logins)
+	}
+	c.Close()
+	if len(bmc.deleted) != 1 || bmc.deleted[0] != "token-2" {
+		t.Errorf("deleted sessions = %v, want [token-2]", bmc.deleted)
+	}
+}
+
+func TestSessionBasicFallback(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			bmc, addr := newSessionBMC(t, status)
+			c, _ := NewRedfishClient(context.Background(), addr, testLogin, TLSOptions{Insecure: true})
+			for i := 0; i < 2; i++ {
+				if _, err := c.Get(context.Background(), "/"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if bmc.basicAuth != 2 {
+				t.Errorf("basic auth requests = %d, want 2", bmc.basicAuth)
+			}
+			if err := c.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if len(bmc.deleted) != 0 {
+				t.Errorf("deleted sessions = %v, want none", bmc.deleted)
+			}
+		})
+	}
+}
+
+func TestSessionLoginRejected(t *testing.T) {
+	_, addr := newSessionBMC(t, http.StatusUnauthorized)
+	c, _ := NewRedfishClient(context.Background(), addr, testLogin, TLSOptions{Insecure: true})
+	if _, err := c.Get(context.Background(), "/"); err == nil || !strings.Contains(err.Error(), "status code 401") {
+		t.Errorf("Get error = %v, want a failed login", err)
+	}
+}
+
+func TestCollectTargetClosesSession(t *testing.T) {
+	bmc, addr := newSessionBMC(t, 0)
+	// The service root reads fine but there are no Systems, so discovery
+	// fails before anything is posted.
+	result := collectTarget(context.Background(), nil, Target{Address: addr, Username: "root", Password: "secret"}, Options{TLS: TLSOptions{Insecure: true}})
+	if result.Err == nil {
+		t.Fatal("collectTarget succeeded against an empty service")
+	}
+	if bmc.logins != 1 || len(bmc.deleted) != 1 {
+		t.Errorf("logins = %d, deleted sessions = %v, want the one session deleted", bmc.logins, bmc.deleted)
+	}
+}
@@ -0,0 +1,125 @@
+package collector
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// --- Collection Targets ---
+
+// maxCIDRHosts caps how many addresses one CIDR may expand to, so a typo like
+// 10.0.0.0/8 does not queue sixteen million BMCs.
+const maxCIDRHosts = 65536
+
+// Target is one BMC to collect from. Without credentials of its own, the
+// login comes from the run's CredentialProvider.
+type Target struct {
+	Address     string `yaml:"address" json:"address"`
+	Username    string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password    string `yaml:"password,omitempty" json:"password,omitempty"`
+	Fingerprint string `yaml:"fingerprint,omitempty" json:"fingerprint,omitempty"` // SHA-256 certificate pin
+}
+
+// targetsFile is the layout of a --targets-file. YAML and JSON both parse.
+//
+//	targets:
+//	  - address: 10.0.0.5
+//	    username: admin
+//	    password: secret
+//	    fingerprint: 3f:a2:...   # accept only this certificate (SHA-256)
+//	  - address: 10.0.1.0/28   # every host in the range, same credentials
+//	  - address: http://localhost:8000   # plain HTTP, e.g. a Redfish emulator
+type targetsFile struct {
+	Targets []Target `yaml:"targets"`
+}
+
+// LoadTargetsFile reads targets from a YAML or JSON file. An address may be a
+// CIDR range, which is expanded with the entry's credentials.
+func LoadTargetsFile(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets file: %w", err)
+	}
+	var file targetsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse targets file %s: %w", path, err)
+	}
+
+	var targets []Target
+	for i, entry := range file.Targets {
+		if entry.Address == "" {
+			return nil, fmt.Errorf("targets file %s: entry %d has no address", path, i+1)
+		}
+		if entry.Fingerprint != "" {
+			fp, err := NormalizeFingerprint(entry.Fingerprint)
+			if err != nil {
+				return nil, fmt.Errorf("targets file %s: entry %d: %w", path, i+1, err)
+			}
+			entry.Fingerprint = fp
+		}
+		if strings.Contains(entry.Address, "://") || !strings.Contains(entry.Address, "/") {
+			targets = append(targets, entry)
+			continue
+		}
+		hosts, err := ExpandCIDR(entry.Address)
+		if err != nil {
+			return nil, fmt.Errorf("targets file %s: entry %d: %w", path, i+1, err)
+		}
+		for _, host := range hosts {
+			targets = append(targets, Target{Address: host, Username: entry.Username, Password: entry.Password, Fingerprint: entry.Fingerprint})
+		}
+	}
+	return targets, nil
+}
+
+// ExpandCIDR lists the host addresses in a CIDR range. For IPv4 prefixes
+// shorter than /31 the network and broadcast addresses are left out.
+func ExpandCIDR(cidr string) ([]string, error) {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR %q: %w", cidr, err)
+	}
+	prefix = prefix.Masked()
+	hostBits := prefix.Addr().BitLen() - prefix.Bits()
+	if hostBits > 16 {
+		return nil, fmt.Errorf("CIDR %s is larger than %d addresses", cidr, maxCIDRHosts)
+	}
+
+	var hosts []string
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		hosts = append(hosts, addr.String())
+	}
+	if prefix.Addr().Is4() && hostBits >= 2 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return hosts, nil
+}
+
+// MergeTargets combines target lists, dropping repeated addresses. A later
+// entry fills in the credentials or fingerprint an earlier one lacks, so a
+// targets file can supply them for an address also given with --ip.
+func MergeTargets(lists ...[]Target) []Target {
+	var merged []Target
+	index := make(map[string]int)
+	for _, list := range lists {
+		for _, t := range list {
+			i, seen := index[t.Address]
+			if !seen {
+				index[t.Address] = len(merged)
+				merged = append(merged, t)
+				continue
+			}
+			if merged[i].Username == "" && merged[i].Password == "" {
+				merged[i].Username, merged[i].Password = t.Username, t.Password
+			}
+			if merged[i].Fingerprint == "" {
+				merged[i].Fingerprint = t.Fingerprint
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpandCIDR(t *testing.T) {
+	tests := []struct {
+		cidr    string
+		count   int
+		first   string
+		last    string
+		wantErr string
+	}{
+		{cidr: "10.0.1.0/30", count: 2, first: "10.0.1.1", last: "10.0.1.2"},
+		{cidr: "10.0.1.5/29", count: 6, first: "10.0.1.1", last: "10.0.1.6"}, // Masked to the network
+		{cidr: "10.0.1.0/31", count: 2, first: "10.0.1.0", last: "10.0.1.1"},
+		{cidr: "10.0.1.7/32", count: 1, first: "10.0.1.7", last: "10.0.1.7"},
+		{cidr: "10.0.0.0/16", count: 65534, first: "10.0.0.1", last: "10.0.255.254"},
+		{cidr: "fd00::/126", count: 4, first: "fd00::", last: "fd00::3"},
+		{cidr: "10.0.0.0/15", wantErr: "larger than 65536 addresses"},
+		{cidr: "10.0.0.0/33", wantErr: "invalid CIDR"},
+	}
+	for _, tt := range tests {
+		hosts, err := ExpandCIDR(tt.cidr)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ExpandCIDR(%s) error = %v, want %q", tt.cidr, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ExpandCIDR(%s): %v", tt.cidr, err)
+			continue
+		}
+		if len(hosts) != tt.count || hosts[0] != tt.first || hosts[len(hosts)-1] != tt.last {
+			t.Errorf("ExpandCIDR(%s) = %d hosts %s..%s, want %d hosts %s..%s",
+				tt.cidr, len(hosts), hosts[0], hosts[len(hosts)-1], tt.count, tt.first, tt.last)
+		}
+	}
+}
+
+func TestLoadTargetsFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "targets.yaml")
+	data := `targets:
+  - address: 10.0.0.5
+    username: admin
+    password: secret
+  - address: 10.0.1.0/30
+    username: root
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadTargetsFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Target{
+		{Address: "10.0.0.5", Username: "admin", Password: "secret"},
+		{Address: "10.0.1.1", Username: "root"},
+		{Address: "10.0.1.2", Username: "root"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadTargetsFile = %+v, want %+v", got, want)
+	}
+
+	if err := os.WriteFile(path, []byte("targets:\n  - username: admin\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTargetsFile(path); err == nil || !strings.Contains(err.Error(), "entry 1 has no address") {
+		t.Errorf("LoadTargetsFile error = %v, want a missing address error", err)
+	}
+}
+
+func TestMergeTargets(t *testing.T) {
+	got := MergeTargets(
+		[]Target{{Address: "10.0.0.1"}, {Address: "10.0.0.2", Username: "cli"}},
+		[]Target{{Address: "10.0.0.1", Username: "file"}, {Address: "10.0.0.2", Username: "file"}, {Address: "10.0.0.3"}},
+	)
+	want := []Target{
+		{Address: "10.0.0.1", Username: "file"}, // Credentials fill in a bare address
+		{Address: "10.0.0.2", Username: "cli"},  // Existing credentials are kept
+		{Address: "10.0.0.3"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeTargets = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,186 @@
+package collector
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// --- BMC Transport Security ---
+
+// TLSOptions controls how BMC certificates are verified. With none of the
+// fields set, certificates must chain to the system roots and name the BMC.
+type TLSOptions struct {
+	RootCAs     *x509.CertPool // Trusted instead of the system roots; see LoadCABundle
+	Fingerprint string         // SHA-256 of the BMC's certificate; only that certificate is accepted
+	KnownHosts  *KnownHosts    // Trust-on-first-use store; nil disables TOFU
+	Insecure    bool           // Accept any certificate
+}
+
+// LoadCABundle reads a PEM file of CA certificates.
+func LoadCABundle(filename string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s contains no PEM certificates", filename)
+	}
+	return pool, nil
+}
+
+// NormalizeFingerprint accepts a SHA-256 fingerprint as plain or
+// colon-separated hex, optionally prefixed "sha256:", and returns it as
+// lowercase hex.
+func NormalizeFingerprint(fp string) (string, error) {
+	fp = strings.ToLower(strings.TrimSpace(fp))
+	fp = strings.TrimPrefix(fp, "sha256:")
+	fp = strings.ReplaceAll(fp, ":", "")
+	if b, err := hex.DecodeString(fp); err != nil || len(b) != sha256.Size {
+		return "", fmt.Errorf("invalid SHA-256 fingerprint %q", fp)
+	}
+	return fp, nil
+}
+
+// certFingerprint is the lowercase hex SHA-256 of a DER certificate.
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// tlsConfig builds the client TLS configuration for one BMC. Pinned and TOFU
+// connections skip the standard chain and hostname checks, since BMC
+// certificates are usually self-signed, and check the fingerprint instead.
+func (o TLSOptions) tlsConfig(address string, logf func(string, ...interface{})) *tls.Config {
+	switch {
+	case o.Fingerprint != "":
+		pin := o.Fingerprint
+		return &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return errors.New("BMC presented no certificate")
+				}
+				if got := certFingerprint(rawCerts[0]); got != pin {
+					return fmt.Errorf("certificate fingerprint %s does not match the pinned %s", got, pin)
+				}
+				return nil
+			},
+		}
+	case o.Insecure:
+		return &tls.Config{InsecureSkipVerify: true}
+	case o.KnownHosts != nil:
+		known := o.KnownHosts
+		return &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+				if len(rawCerts) == 0 {
+					return errors.New("BMC presented no certificate")
+				}
+				added, err := known.Check(address, certFingerprint(rawCerts[0]))
+				if added {
+					logf("Trusting certificate %s on first use; recorded in %s", certFingerprint(rawCerts[0]), known.path)
+				}
+				return err
+			},
+		}
+	}
+	return &tls.Config{RootCAs: o.RootCAs}
+}
+
+// KnownHosts is a trust-on-first-use certificate store. Each line of the file
+// is "<address> <sha256 hex>"; lines starting with # are comments. An address
+// seen for the first time has its certificate recorded; afterwards only that
+// certificate is accepted. Delete the line to accept a replaced certificate.
+type KnownHosts struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]string
+}
+
+// LoadKnownHosts reads a known-hosts file. A missing file is an empty store,
+// created on the first new entry.
+func LoadKnownHosts(filename string) (*KnownHosts, error) {
+	k := &KnownHosts{path: filename, entries: make(map[string]string)}
+	f, err := os.Open(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open known hosts file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("known hosts file %s: line %d: want \"<address> <fingerprint>\"", filename, line)
+		}
+		fp, err := NormalizeFingerprint(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("known hosts file %s: line %d: %w", filename, line, err)
+		}
+		k.entries[fields[0]] = fp
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read known hosts file: %w", err)
+	}
+	return k, nil
+}
+
+// Check accepts fp for address if it matches the recorded fingerprint, or
+// records it if the address is new. It reports whether an entry was added.
+func (k *KnownHosts) Check(address, fp string) (bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if known, ok := k.entries[address]; ok {
+		if known != fp {
+			return false, fmt.Errorf("certificate for %s has changed (recorded %s, presented %s); remove its line from %s if the change is expected", address, known, fp, k.path)
+		}
+		return false, nil
+	}
+
+	f, err := os.OpenFile(k.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return false, fmt.Errorf("failed to record certificate for %s: %w", address, err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%s %s\n", address, fp); err != nil {
+		return false, fmt.Errorf("failed to record certificate for %s: %w", address, err)
+	}
+	k.entries[address] = fp
+	return true, nil
+}
+
+// splitScheme separates an optional http:// or https:// prefix from a BMC
+// address. Addresses without one use HTTPS.
+func splitScheme(address string) (scheme, host string, err error) {
+	if !strings.Contains(address, "://") {
+		return "https", address, nil
+	}
+	u, err := url.Parse(address)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid BMC address %q: %w", address, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", fmt.Errorf("invalid BMC address %q: scheme must be http or https", address)
+	}
+	if u.Host == "" || (u.Path != "" && u.Path != "/") {
+		return "", "", fmt.Errorf("invalid BMC address %q: want scheme://host[:port]", address)
+	}
+	return u.Scheme, u.Host, nil
+}
@@ -0,0 +1,181 @@
+package collector
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestCert makes a self-signed certificate for 127.0.0.1, as a BMC would present.
+func newTestCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "bmc"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newCertBMC starts a BMC presenting whichever certificate cert holds when a
+// connection is made, so a test can swap it.
+func newCertBMC(t *testing.T, cert *atomic.Pointer[tls.Certificate]) string {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{}`))
+	}))
+	srv.TLS = &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return &tls.Config{Certificates: []tls.Certificate{*cert.Load()}}, nil
+		},
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "https://")
+}
+
+// getRoot reads the service root over a fresh connection.
+func getRoot(t *testing.T, addr string, opts TLSOptions) error {
+	t.Helper()
+	c, err := NewRedfishClient(context.Background(), addr, testLogin, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.AuthMode = AuthBasic
+	_, err = c.Get(context.Background(), "/")
+	return err
+}
+
+func TestTLSFingerprint(t *testing.T) {
+	cert, other := newTestCert(t), newTestCert(t)
+	var current atomic.Pointer[tls.Certificate]
+	current.Store(&cert)
+	addr := newCertBMC(t, &current)
+
+	if err := getRoot(t, addr, TLSOptions{}); err == nil {
+		t.Error("self-signed certificate accepted without a pin")
+	}
+	if err := getRoot(t, addr, TLSOptions{Fingerprint: certFingerprint(cert.Certificate[0])}); err != nil {
+		t.Errorf("pinned certificate refused: %v", err)
+	}
+	err := getRoot(t, addr, TLSOptions{Fingerprint: certFingerprint(other.Certificate[0]), Insecure: true})
+	if err == nil || !strings.Contains(err.Error(), "does not match the pinned") {
+		t.Errorf("mismatched pin error = %v, want a fingerprint mismatch", err)
+	}
+}
+
+func TestTLSRootCAs(t *testing.T) {
+	cert := newTestCert(t)
+	var current atomic.Pointer[tls.Certificate]
+	current.Store(&cert)
+	addr := newCertBMC(t, &current)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	if err := getRoot(t, addr, TLSOptions{RootCAs: pool}); err != nil {
+		t.Errorf("certificate from the CA bundle refused: %v", err)
+	}
+}
+
+func TestTLSKnownHosts(t *testing.T) {
+	cert, replaced := newTestCert(t), newTestCert(t)
+	var current atomic.Pointer[tls.Certificate]
+	current.Store(&cert)
+	addr := newCertBMC(t, &current)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+
+	known, err := LoadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getRoot(t, addr, TLSOptions{KnownHosts: known}); err != nil {
+		t.Fatalf("first use refused: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := addr + " " + certFingerprint(cert.Certificate[0]) + "\n"; string(data) != want {
+		t.Errorf("known hosts file = %q, want %q", data, want)
+	}
+
+	// A later run trusts the recorded certificate and nothing else.
+	known, err = LoadKnownHosts(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := getRoot(t, addr, TLSOptions{KnownHosts: known}); err != nil {
+		t.Errorf("recorded certificate refused: %v", err)
+	}
+	current.Store(&replaced)
+	if err := getRoot(t, addr, TLSOptions{KnownHosts: known}); err == nil || !strings.Contains(err.Error(), "has changed") {
+		t.Errorf("changed certificate error = %v, want it refused", err)
+	}
+}
+
+func TestNormalizeFingerprint(t *testing.T) {
+	want := strings.Repeat("ab", 32)
+	for _, fp := range []string{want, strings.ToUpper(want), "sha256:" + want, strings.TrimSuffix(strings.Repeat("AB:", 32), ":")} {
+		if got, err := NormalizeFingerprint(fp); err != nil || got != want {
+			t.Errorf("NormalizeFingerprint(%s) = %s, %v, want %s", fp, got, err, want)
+		}
+	}
+	for _, fp := range []string{"", "abcd", strings.Repeat("zz", 32)} {
+		if _, err := NormalizeFingerprint(fp); err == nil {
+			t.Errorf("NormalizeFingerprint(%q) succeeded", fp)
+		}
+	}
+}
+
+func TestSplitScheme(t *testing.T) {
+	tests := []struct {
+		address, scheme, host string
+	}{
+		{"10.0.0.1", "https", "10.0.0.1"},
+		{"10.0.0.1:8443", "https", "10.0.0.1:8443"},
+		{"http://localhost:8000", "http", "localhost:8000"},
+		{"https://bmc/", "https", "bmc"},
+		{"ftp://bmc", "", ""},
+		{"http://bmc/redfish/v1", "", ""},
+	}
+	for _, tt := range tests {
+		scheme, host, err := splitScheme(tt.address)
+		if tt.scheme == "" {
+			if err == nil {
+				t.Errorf("splitScheme(%s) succeeded", tt.address)
+			}
+			continue
+		}
+		if err != nil || scheme != tt.scheme || host != tt.host {
+			t.Errorf("splitScheme(%s) = %s, %s, %v, want %s, %s", tt.address, scheme, host, err, tt.scheme, tt.host)
+		}
+	}
+}
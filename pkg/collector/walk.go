@@ -0,0 +1,398 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	// Import the API's canonical resource definition
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// --- Redfish Tree Walk ---
+//
+// The walker visits Systems, then Chassis, then Managers, then firmware:
+//
+//	/Systems/{id}                     Node
+//	  Processors/{id}                 CPU, or GPU, FPGA or Accelerator by ProcessorType
+//	    Links.PCIeDevice, Memory     merged into the GPU, FPGA or Accelerator
+//	  Memory/{id}                     DIMM
+//	  Storage/{id}                    StorageController
+//	    Drives[]                      Disk
+//	  NetworkInterfaces/{id} -> Links.NetworkAdapter   NIC
+//	  PCIeDevices[]                   PCIeDevice
+//	/Chassis/{id}                     Chassis (Rack or Blade by ChassisType)
+//	  NetworkAdapters/{id}            NIC
+//	  PCIeDevices/{id}                PCIeDevice
+//	  PowerSubsystem/PowerSupplies    PowerSupply (or Power.PowerSupplies[])
+//	  ThermalSubsystem/Fans           Fan (or Thermal.Fans[])
+//	/Managers/{id}                    BMC
+//	/UpdateService/FirmwareInventory  firmware.* properties of the devices above
+//
+// A resource reachable along more than one path is reported once, under the
+// first parent it was found through. Systems go first, so a NIC or PCIe device
+// belonging to a node hangs off the Node as in populate_node.sh.
+//
+// Once everything is walked, chassis Links rebuild the physical hierarchy:
+// each chassis goes under its Links.ContainedBy (or whichever chassis lists it
+// in Links.Contains), and each Node goes under the innermost chassis that lists
+// it in Links.ComputerSystems (or that it lists in its own Links.Chassis),
+// giving Rack -> Enclosure -> Blade -> Node -> components.
+
+// treeWalker accumulates devices while walking one BMC.
+type treeWalker struct {
+	c        *RedfishClient
+	statuses []*device.DeviceStatus
+	seen     map[string]bool                 // Redfish URIs already reported
+	byURI    map[string]*device.DeviceStatus // Reported devices, for re-parenting
+	// Redfish URIs reported as part of another device, such as an
+	// accelerator's PCIe card, mapped to that device's URI.
+	mergedInto map[string]string
+
+	// Containment links gathered during the walk, by chassis or system URI.
+	chassisOrder  []string
+	containedBy   map[string]string
+	systemChassis map[string][]string
+}
+
+// discoverDevices walks the Redfish tree and returns every device found.
+// Only an unreadable Systems collection is fatal; anything else is recorded
+// as a warning and the walk carries on. root is the service root, or nil if
+// it could not be read.
+func discoverDevices(ctx context.Context, c *RedfishClient, root *RedfishServiceRoot) ([]*device.DeviceStatus, error) {
+	w := &treeWalker{
+		c:             c,
+		seen:          make(map[string]bool),
+		byURI:         make(map[string]*device.DeviceStatus),
+		mergedInto:    make(map[string]string),
+		containedBy:   make(map[string]string),
+		systemChassis: make(map[string][]string),
+	}
+
+	systems, err := w.members(ctx, "/Systems")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Systems collection: %w", err)
+	}
+	for _, uri := range systems {
+		w.walkSystem(ctx, uri)
+	}
+
+	chassis, err := w.members(ctx, "/Chassis")
+	if err != nil {
+		c.warnf("/Chassis", "failed to get Chassis collection: %v", err)
+	}
+	for _, uri := range chassis {
+		w.walkChassis(ctx, uri)
+	}
+
+	managers, err := w.members(ctx, "/Managers")
+	if err != nil {
+		c.warnf("/Managers", "failed to get Managers collection: %v", err)
+	}
+	for _, member := range c.GetAll(ctx, managers) {
+		if member.Err != nil {
+			c.warnf(member.URI, "failed to get manager %s: %v", member.URI, member.Err)
+			continue
+		}
+		w.addBody(member.Body, "BMC", member.URI, "")
+	}
+
+	// Firmware goes on the devices found above
+	if root != nil {
+		w.firmware(ctx, root.UpdateService)
+	}
+
+	// A walk cut short by the deadline is missing devices for reasons other
+	// than the BMC's; do not pass it off as a collection with warnings.
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("discovery interrupted: %w", err)
+	}
+
+	w.linkContainment()
+	return w.statuses, nil
+}
+
+// walkSystem discovers a single system (Node) and its children.
+func (w *treeWalker) walkSystem(ctx context.Context, systemURI string) {
+	var system RedfishSystem
+	body, err := w.get(ctx, systemURI, &system)
+	if err != nil {
+		w.c.warnf(systemURI, "failed to get inventory for system %s: %v", systemURI, err)
+		return
+	}
+	w.add(system.CommonRedfishProperties, body, "Node", systemURI, "") // Parent chassis is filled in by linkContainment
+	for _, link := range system.Links.Chassis {
+		w.systemChassis[systemURI] = append(w.systemChassis[systemURI], cleanURI(link.ODataID))
+	}
+
+	w.processors(ctx, system.Processors, systemURI)
+	w.components(ctx, system.Memory, "DIMM", systemURI)
+
+	w.eachMember(ctx, system.Storage, func(storageURI string, body []byte) {
+		var storage RedfishStorage
+		if err := json.Unmarshal(body, &storage); err != nil {
+			w.c.warnf(storageURI, "failed to unmarshal storage %s: %v", storageURI, err)
+			return
+		}
+		props := storage.CommonRedfishProperties
+		if props.SerialNumber == "" && len(storage.StorageControllers) == 1 {
+			props = storage.StorageControllers[0]
+		}
+		w.add(props, body, "StorageController", storageURI, systemURI)
+		w.linkedComponents(ctx, storage.Drives, "Disk", storageURI)
+	})
+
+	// NetworkInterfaces are the system's view of its adapters; the adapter holds the hardware details.
+	w.eachMember(ctx, system.NetworkInterfaces, func(nicURI string, body []byte) {
+		var nic RedfishNetworkInterface
+		if err := json.Unmarshal(body, &nic); err != nil {
+			w.c.warnf(nicURI, "failed to unmarshal network interface %s: %v", nicURI, err)
+			return
+		}
+		if adapter := nic.Links.NetworkAdapter; adapter.ODataID != "" {
+			w.linkedComponents(ctx, []ODataLink{adapter}, "NIC", systemURI)
+		}
+	})
+
+	w.linkedComponents(ctx, system.PCIeDevices, "PCIeDevice", systemURI)
+}
+
+// walkChassis discovers a chassis and the components attached to it.
+func (w *treeWalker) walkChassis(ctx context.Context, chassisURI string) {
+	var chassis RedfishChassis
+	body, err := w.get(ctx, chassisURI, &chassis)
+	if err != nil {
+		w.c.warnf(chassisURI, "failed to get chassis %s: %v", chassisURI, err)
+		return
+	}
+	w.add(chassis.CommonRedfishProperties, body, chassisDeviceType(chassis.ChassisType), chassisURI, "")
+	if status := w.byURI[chassisURI]; status != nil {
+		snapshotformat.SetStringProperty(status, "chassis_type", chassis.ChassisType)
+	}
+	w.chassisOrder = append(w.chassisOrder, chassisURI)
+	if container := cleanURI(chassis.Links.ContainedBy.ODataID); container != "" {
+		w.containedBy[chassisURI] = container
+	}
+	for _, link := range chassis.Links.Contains {
+		if child := cleanURI(link.ODataID); child != "" {
+			if _, known := w.containedBy[child]; !known {
+				w.containedBy[child] = chassisURI
+			}
+		}
+	}
+	for _, link := range chassis.Links.ComputerSystems {
+		systemURI := cleanURI(link.ODataID)
+		w.systemChassis[systemURI] = append(w.systemChassis[systemURI], chassisURI)
+	}
+
+	w.components(ctx, chassis.NetworkAdapters, "NIC", chassisURI)
+	w.components(ctx, chassis.PCIeDevices, "PCIeDevice", chassisURI)
+
+	if link := chassis.PowerSubsystem.ODataID; link != "" {
+		var power RedfishPowerSubsystem
+		if _, err := w.get(ctx, cleanURI(link), &power); err != nil {
+			w.c.warnf(link, "failed to get power subsystem %s: %v", link, err)
+		} else {
+			w.components(ctx, power.PowerSupplies, "PowerSupply", chassisURI)
+		}
+	} else if link := chassis.Power.ODataID; link != "" {
+		var power RedfishPower
+		if _, err := w.get(ctx, cleanURI(link), &power); err != nil {
+			w.c.warnf(link, "failed to get power %s: %v", link, err)
+		} else {
+			w.embedded(power.PowerSupplies, "PowerSupply", chassisURI)
+		}
+	}
+
+	if link := chassis.ThermalSubsystem.ODataID; link != "" {
+		var thermal RedfishThermalSubsystem
+		if _, err := w.get(ctx, cleanURI(link), &thermal); err != nil {
+			w.c.warnf(link, "failed to get thermal subsystem %s: %v", link, err)
+		} else {
+			w.components(ctx, thermal.Fans, "Fan", chassisURI)
+		}
+	} else if link := chassis.Thermal.ODataID; link != "" {
+		var thermal RedfishThermal
+		if _, err := w.get(ctx, cleanURI(link), &thermal); err != nil {
+			w.c.warnf(link, "failed to get thermal %s: %v", link, err)
+		} else {
+			w.embedded(thermal.Fans, "Fan", chassisURI)
+		}
+	}
+}
+
+// linkContainment points chassis at their containers and nodes at their
+// innermost chassis, using the links gathered during the walk.
+func (w *treeWalker) linkContainment() {
+	for _, chassisURI := range w.chassisOrder {
+		// A chassis' own ContainedBy wins over another chassis claiming it in Contains
+		if container, ok := w.containedBy[chassisURI]; ok && container != chassisURI {
+			w.setParent(chassisURI, container)
+		}
+	}
+
+	for systemURI, candidates := range w.systemChassis {
+		best, bestDepth := "", -1
+		for _, chassisURI := range candidates {
+			if depth := w.chassisDepth(chassisURI); depth > bestDepth {
+				best, bestDepth = chassisURI, depth
+			}
+		}
+		if best != "" {
+			w.setParent(systemURI, best)
+		}
+	}
+}
+
+// chassisDepth counts the containers above a chassis; deeper means more specific
+// (a blade is deeper than its enclosure). Loops in bad Links data are cut short.
+func (w *treeWalker) chassisDepth(chassisURI string) int {
+	visited := map[string]bool{chassisURI: true}
+	depth := 0
+	for uri := w.containedBy[chassisURI]; uri != "" && !visited[uri]; uri = w.containedBy[uri] {
+		visited[uri] = true
+		depth++
+	}
+	return depth
+}
+
+// setParent rewrites the redfish_parent_uri of a reported device.
+func (w *treeWalker) setParent(uri, parentURI string) {
+	if status := w.byURI[uri]; status != nil {
+		snapshotformat.SetStringProperty(status, "redfish_parent_uri", parentURI)
+	}
+}
+
+// chassisDeviceType maps a Redfish ChassisType to a device type. Racks and
+// blades get their own types; enclosures, rack-mount servers and the rest are Chassis.
+func chassisDeviceType(chassisType string) string {
+	switch chassisType {
+	case "Rack":
+		return "Rack"
+	case "Blade":
+		return "Blade"
+	}
+	return "Chassis"
+}
+
+// components adds every member of a collection as deviceType under parentURI.
+func (w *treeWalker) components(ctx context.Context, collection ODataLink, deviceType, parentURI string) {
+	w.eachMember(ctx, collection, func(uri string, body []byte) {
+		w.addBody(body, deviceType, uri, parentURI)
+	})
+}
+
+// linkedComponents adds every linked resource as deviceType under parentURI.
+func (w *treeWalker) linkedComponents(ctx context.Context, links []ODataLink, deviceType, parentURI string) {
+	var uris []string
+	for _, link := range links {
+		if uri := cleanURI(link.ODataID); uri != "" && !w.seen[uri] {
+			uris = append(uris, uri)
+		}
+	}
+	for _, member := range w.c.GetAll(ctx, uris) {
+		if member.Err != nil {
+			w.c.warnf(member.URI, "failed to get %s %s: %v", deviceType, member.URI, member.Err)
+			continue
+		}
+		w.addBody(member.Body, deviceType, member.URI, parentURI)
+	}
+}
+
+// embedded adds the array members of a deprecated Power or Thermal resource.
+func (w *treeWalker) embedded(members []json.RawMessage, deviceType, parentURI string) {
+	for _, body := range members {
+		var member RedfishEmbeddedMember
+		if err := json.Unmarshal(body, &member); err != nil {
+			w.c.warnf(parentURI, "failed to unmarshal embedded %s of %s: %v", deviceType, parentURI, err)
+			continue
+		}
+		if uri := cleanURI(member.ODataID); uri != "" {
+			w.add(member.CommonRedfishProperties, body, deviceType, uri, parentURI)
+		}
+	}
+}
+
+// eachMember fetches every member of the collection at link and hands it to fn.
+// Members already reported along another path are skipped without a request.
+func (w *treeWalker) eachMember(ctx context.Context, collection ODataLink, fn func(uri string, body []byte)) {
+	if collection.ODataID == "" {
+		return
+	}
+	members, err := w.c.GetCollection(ctx, cleanURI(collection.ODataID), func(uri string) bool { return w.seen[uri] })
+	if err != nil {
+		w.c.warnf(collection.ODataID, "failed to retrieve collection %s: %v", collection.ODataID, err)
+		return
+	}
+	for _, member := range members {
+		if member.Err != nil {
+			w.c.warnf(member.URI, "failed to get member %s: %v", member.URI, member.Err)
+			continue
+		}
+		fn(member.URI, member.Body)
+	}
+}
+
+// members returns the cleaned member URIs of a collection.
+func (w *treeWalker) members(ctx context.Context, collectionURI string) ([]string, error) {
+	var collection RedfishCollection
+	if _, err := w.get(ctx, collectionURI, &collection); err != nil {
+		return nil, err
+	}
+	uris := make([]string, 0, len(collection.Members))
+	for _, member := range collection.Members {
+		uris = append(uris, cleanURI(member.ODataID))
+	}
+	return uris, nil
+}
+
+// get fetches a Redfish resource, decodes it into v and returns the body.
+func (w *treeWalker) get(ctx context.Context, uri string, v interface{}) ([]byte, error) {
+	body, err := w.c.Get(ctx, uri)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", uri, err)
+	}
+	return body, nil
+}
+
+// addBody decodes the common properties of a fetched resource and adds it.
+func (w *treeWalker) addBody(body []byte, deviceType, uri, parentURI string) {
+	var props CommonRedfishProperties
+	if err := json.Unmarshal(body, &props); err != nil {
+		w.c.warnf(uri, "failed to unmarshal component %s: %v", uri, err)
+		return
+	}
+	w.add(props, body, deviceType, uri, parentURI)
+}
+
+// add maps a resource and records it, unless it was already reported. body
+// is the resource as fetched, for the client's PropertyMap.
+func (w *treeWalker) add(props CommonRedfishProperties, body []byte, deviceType, uri, parentURI string) {
+	if w.seen[uri] {
+		return
+	}
+	w.seen[uri] = true
+	status := mapCommonProperties(props, deviceType, uri, parentURI)
+	w.c.PropertyMap.apply(status, body)
+	w.byURI[uri] = status
+	w.statuses = append(w.statuses, status)
+}
+
+// setIntProperty stores a number property; 0 is skipped.
+func setIntProperty(status *device.DeviceStatus, key string, value int) {
+	if value == 0 {
+		return
+	}
+	raw, _ := json.Marshal(value)
+	status.Properties[key] = raw
+}
+
+// cleanURI strips the service root prefix; redfish_uri values are relative to /redfish/v1.
+func cleanURI(odataID string) string {
+	return strings.TrimPrefix(odataID, "/redfish/v1")
+}
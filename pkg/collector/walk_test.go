@@ -0,0 +1,312 @@
+package collector
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/user/inventory-api/pkg/redfishmock"
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// walkMockup serves a mockup over HTTPS and walks it the way collectTarget
+// does, returning the devices found and the URIs of the warnings.
+func walkMockup(t *testing.T, mockup fs.FS) ([]*device.DeviceStatus, []string) {
+	t.Helper()
+	srv, err := redfishmock.NewServer(mockup)
+	if err != nil {
+		t.Fatalf("NewServer: %v", err)
+	}
+	defer srv.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	ctx := context.Background()
+	c, err := NewRedfishClient(ctx, srv.Address(), StaticCredentials{Username: "admin", Password: "secret"}, TLSOptions{RootCAs: pool})
+	if err != nil {
+		t.Fatalf("NewRedfishClient: %v", err)
+	}
+	defer c.Close()
+	c.Retry.BaseDelay = 0 // server-errors fails some URIs only for their first requests
+
+	root, err := getServiceRoot(ctx, c)
+	if err != nil {
+		t.Fatalf("getServiceRoot: %v", err)
+	}
+	c.ExpandQuery = expandQuery(root.ProtocolFeaturesSupported.ExpandQuery)
+	statuses, err := discoverDevices(ctx, c, root)
+	if err != nil {
+		t.Fatalf("discoverDevices: %v", err)
+	}
+	var warnings []string
+	for _, w := range c.warnings {
+		warnings = append(warnings, cleanURI(w.URI))
+	}
+	sort.Strings(warnings)
+	return statuses, warnings
+}
+
+func bundledMockup(t *testing.T, name string) fs.FS {
+	t.Helper()
+	mockup, err := redfishmock.Mockup(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mockup
+}
+
+func TestDiscoverDevicesMockups(t *testing.T) {
+	tests := []struct {
+		mockup   string
+		devices  map[string]int    // Count by device type
+		parents  map[string]string // redfish_uri -> redfish_parent_uri, for a sample
+		warnings []string
+	}{
+		{
+			mockup: "single-node",
+			devices: map[string]int{
+				"Node": 1, "CPU": 2, "DIMM": 4, "StorageController": 1, "Disk": 2, "NIC": 1,
+				"Chassis": 1, "PowerSupply": 2, "Fan": 2, "BMC": 1,
+			},
+			parents: map[string]string{
+				"/Systems/1":                            "/Chassis/1",
+				"/Systems/1/Processors/CPU1":            "/Systems/1",
+				"/Systems/1/Storage/RAID1/Drives/0":     "/Systems/1/Storage/RAID1",
+				"/Chassis/1/NetworkAdapters/NIC1":       "/Systems/1", // Reported once, under the node
+				"/Chassis/1/ThermalSubsystem/Fans/Fan1": "/Chassis/1",
+				"/Chassis/1":                            "",
+				"/Managers/BMC":                         "",
+			},
+		},
+		{
+			mockup: "gpu-node",
+			// The GPU and FPGA cards are merged into their processors, and
+			// GPU HBM is not counted among the DIMMs
+			devices: map[string]int{
+				"Node": 1, "CPU": 2, "GPU": 4, "FPGA": 1, "DIMM": 4, "StorageController": 1, "Disk": 2,
+				"NIC": 1, "PCIeDevice": 1, "Chassis": 1, "PowerSupply": 2, "Fan": 2, "BMC": 1,
+			},
+			parents: map[string]string{
+				"/Systems/1/Processors/GPU3":     "/Systems/1",
+				"/Systems/1/Processors/FPGA1":    "/Systems/1",
+				"/Chassis/1/PCIeDevices/Switch1": "/Chassis/1",
+			},
+		},
+		{
+			mockup: "multi-node-chassis",
+			devices: map[string]int{
+				"Rack": 1, "Chassis": 1, "Blade": 4, "Node": 4, "CPU": 8, "DIMM": 8,
+				"StorageController": 4, "Disk": 4, "PowerSupply": 4, "Fan": 6, "BMC": 1,
+			},
+			parents: map[string]string{
+				"/Chassis/Rack1":                 "",
+				"/Chassis/Enclosure1":            "/Chassis/Rack1",
+				"/Chassis/Blade3":                "/Chassis/Enclosure1",
+				"/Systems/Node3":                 "/Chassis/Blade3",
+				"/Systems/Node3/Processors/CPU2": "/Systems/Node3",
+				"/Systems/Node3/Storage/RAID1":   "/Systems/Node3",
+			},
+		},
+		{
+			mockup: "missing-members",
+			devices: map[string]int{
+				"Node": 1, "CPU": 1, "DIMM": 2, "StorageController": 1, "Disk": 2, "NIC": 1,
+				"Chassis": 1, "PowerSupply": 2, "BMC": 1,
+			},
+			parents: map[string]string{
+				"/Systems/1/Memory/DIMM2": "/Systems/1",
+			},
+			warnings: []string{
+				"/Chassis/1/ThermalSubsystem",
+				"/Systems/1/Memory/DIMM3",
+				"/Systems/1/Memory/DIMM4",
+				"/Systems/1/Processors/CPU2",
+			},
+		},
+		{
+			mockup: "server-errors",
+			// DIMM3 fails only for its first two requests and is read on retry
+			devices: map[string]int{
+				"Node": 1, "DIMM": 3, "StorageController": 1, "Disk": 2, "NIC": 1,
+				"Chassis": 1, "PowerSupply": 2, "Fan": 2,
+			},
+			parents: map[string]string{
+				"/Systems/1/Memory/DIMM3": "/Systems/1",
+			},
+			warnings: []string{
+				"/Managers/BMC",
+				"/Systems/1/Memory/DIMM2",
+				"/Systems/1/Processors",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mockup, func(t *testing.T) {
+			statuses, warnings := walkMockup(t, bundledMockup(t, tt.mockup))
+
+			counts := make(map[string]int)
+			byURI := make(map[string]*device.DeviceStatus)
+			for _, status := range statuses {
+				counts[status.DeviceType]++
+				uri := snapshotformat.StringProperty(status, "redfish_uri")
+				if byURI[uri] != nil {
+					t.Errorf("%s reported twice", uri)
+				}
+				byURI[uri] = status
+			}
+			if !equalCounts(counts, tt.devices) {
+				t.Errorf("device counts = %v, want %v", counts, tt.devices)
+			}
+
+			// Every parent is a device of this walk
+			for uri, status := range byURI {
+				if parent := snapshotformat.StringProperty(status, "redfish_parent_uri"); parent != "" && byURI[parent] == nil {
+					t.Errorf("%s has parent %s, which was not reported", uri, parent)
+				}
+			}
+			for uri, want := range tt.parents {
+				status := byURI[uri]
+				if status == nil {
+					t.Errorf("%s not reported", uri)
+					continue
+				}
+				if got := snapshotformat.StringProperty(status, "redfish_parent_uri"); got != want {
+					t.Errorf("%s parent = %q, want %q", uri, got, want)
+				}
+			}
+
+			if strings.Join(warnings, " ") != strings.Join(tt.warnings, " ") {
+				t.Errorf("warnings = %v, want %v", warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestDiscoverDevicesFirmware(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockup   fs.FS
+		firmware map[string]string // redfish_uri -> its firmware.<component>.version properties
+	}{
+		{
+			name:   "inventory",
+			mockup: bundledMockup(t, "single-node"),
+			firmware: map[string]string{
+				"/Systems/1":                      "firmware.bios.version=2.18.1",
+				"/Chassis/1":                      "firmware.cpld.version=1.0.7",
+				"/Managers/BMC":                   "firmware.bmc.version=2.14.0",
+				"/Chassis/1/NetworkAdapters/NIC1": "firmware.nic.version=20.39.1002",
+			},
+		},
+		{
+			// Firmware linked to an accelerator's PCIe card lands on the accelerator
+			name:   "accelerators",
+			mockup: bundledMockup(t, "gpu-node"),
+			firmware: map[string]string{
+				"/Systems/1":                      "firmware.bios.version=2.18.1",
+				"/Systems/1/Processors/GPU1":      "firmware.gpu.version=96.00.5E.00.01",
+				"/Systems/1/Processors/GPU2":      "firmware.gpu.version=96.00.5E.00.01",
+				"/Systems/1/Processors/GPU3":      "firmware.gpu.version=96.00.5E.00.01",
+				"/Systems/1/Processors/GPU4":      "firmware.gpu.version=96.00.5E.00.01",
+				"/Systems/1/Processors/FPGA1":     "firmware.fpga.version=2.14.354",
+				"/Chassis/1":                      "firmware.cpld.version=1.0.7",
+				"/Managers/BMC":                   "firmware.bmc.version=2.14.0",
+				"/Chassis/1/NetworkAdapters/NIC1": "firmware.nic.version=20.39.1002",
+			},
+		},
+		{
+			// A BMC without an UpdateService has no firmware, and no warning for it
+			name:     "no update service",
+			mockup:   withoutUpdateService(t, bundledMockup(t, "single-node")),
+			firmware: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statuses, warnings := walkMockup(t, tt.mockup)
+			if len(warnings) > 0 {
+				t.Errorf("warnings = %v, want none", warnings)
+			}
+			got := make(map[string]string)
+			for _, status := range statuses {
+				var versions []string
+				for key := range status.Properties {
+					if strings.HasPrefix(key, "firmware.") && strings.HasSuffix(key, ".version") {
+						versions = append(versions, key+"="+snapshotformat.StringProperty(status, key))
+					}
+				}
+				if len(versions) > 0 {
+					sort.Strings(versions)
+					got[snapshotformat.StringProperty(status, "redfish_uri")] = strings.Join(versions, " ")
+				}
+			}
+			if !equalStrings(got, tt.firmware) {
+				t.Errorf("firmware = %v, want %v", got, tt.firmware)
+			}
+		})
+	}
+}
+
+// withoutUpdateService copies a mockup, dropping the UpdateService and the
+// service root's link to it.
+func withoutUpdateService(t *testing.T, mockup fs.FS) fs.FS {
+	t.Helper()
+	copied := fstest.MapFS{}
+	err := fs.WalkDir(mockup, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasPrefix(name, "redfish/v1/UpdateService/") {
+			return err
+		}
+		data, err := fs.ReadFile(mockup, name)
+		if err != nil {
+			return err
+		}
+		if name == "redfish/v1/index.json" {
+			var root map[string]interface{}
+			if err := json.Unmarshal(data, &root); err != nil {
+				return err
+			}
+			delete(root, "UpdateService")
+			if data, err = json.Marshal(root); err != nil {
+				return err
+			}
+		}
+		copied[name] = &fstest.MapFile{Data: data}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return copied
+}
+
+func equalCounts(a, b map[string]int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStrings(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,390 @@
+// pkg/reconcilers/device_ingest.go
+package reconcilers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openchami/fabrica/pkg/resource"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// deviceKind is the storage resource type for Device resources.
+const deviceKind = "Device"
+
+// propRedfishURI is written by the collector (see collector.mapCommonProperties).
+const propRedfishURI = "redfish_uri"
+
+// toDiscoverySnapshot converts whatever the controller hands us into a typed snapshot.
+// The fabrica controller loads resources as raw JSON, so we accept both forms.
+func toDiscoverySnapshot(resource interface{}) (*discoverysnapshot.DiscoverySnapshot, error) {
+	switch res := resource.(type) {
+	case *discoverysnapshot.DiscoverySnapshot:
+		return res, nil
+	case json.RawMessage:
+		return decodeDiscoverySnapshot(res)
+	case []byte:
+		return decodeDiscoverySnapshot(res)
+	default:
+		return nil, fmt.Errorf("invalid resource type, expected *DiscoverySnapshot, got %T", resource)
+	}
+}
+
+func decodeDiscoverySnapshot(data []byte) (*discoverysnapshot.DiscoverySnapshot, error) {
+	snapshot := &discoverysnapshot.DiscoverySnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to decode DiscoverySnapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// loadDevices reads every Device currently in storage.
+func (r *DiscoverySnapshotReconciler) loadDevices(ctx context.Context) ([]*device.Device, error) {
+	rawDevices, err := r.Storage.LoadAll(ctx, deviceKind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load devices: %w", err)
+	}
+	devices := make([]*device.Device, 0, len(rawDevices))
+	for _, raw := range rawDevices {
+		dev := &device.Device{}
+		if err := json.Unmarshal(raw, dev); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal Device: %w", err)
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+// stageDevice queues a single Device write on tx.
+func stageDevice(ctx context.Context, tx *storage.Tx, dev *device.Device) error {
+	data, err := json.Marshal(dev)
+	if err != nil {
+		return fmt.Errorf("failed to marshal Device %s: %w", dev.GetUID(), err)
+	}
+	return tx.Save(ctx, deviceKind, dev.GetUID(), data)
+}
+
+// applyResult collects what applySnapshot did to the inventory.
+type applyResult struct {
+	logs       []string
+	summary    discoverysnapshot.IngestSummary
+	results    []discoverysnapshot.DeviceResult
+	provenance *snapshotformat.Provenance // From the payload envelope; nil if it carried none
+}
+
+// String renders the tallies for the snapshot status message.
+func (res *applyResult) String() string {
+	return fmt.Sprintf("%d created, %d updated, %d unchanged, %d removed, %d failed",
+		res.summary.Created, res.summary.Updated, res.summary.Unchanged, res.summary.Removed, res.summary.Failed)
+}
+
+func (res *applyResult) logf(format string, args ...interface{}) {
+	res.logs = append(res.logs, fmt.Sprintf(format, args...))
+}
+
+// record counts one device outcome and adds its per-device entry.
+func (res *applyResult) record(uid, name, action string, err error) {
+	result := discoverysnapshot.DeviceResult{UID: uid, Name: name, Action: action}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	res.summary.Add(action)
+	res.results = append(res.results, result)
+}
+
+// pendingWrite is a Device the snapshot touched, waiting to be saved.
+type pendingWrite struct {
+	dev       *device.Device
+	action    string
+	before    []byte // Status as loaded from storage; nil for new Devices
+	note      string // Extra detail for the log line
+	parentRef string // The payload's reference to the parent entry
+}
+
+// applySnapshot decodes the snapshot payload and creates or updates the Device
+// resources it describes. Devices the snapshot should have seen but did not are
+// marked as removed. All Device writes are committed together: on error, none
+// of them reached storage.
+func (r *DiscoverySnapshotReconciler) applySnapshot(ctx context.Context, snapshot *discoverysnapshot.DiscoverySnapshot) (*applyResult, error) {
+	res := &applyResult{}
+
+	// 1. Decode the payload with the decoder registered for its format
+	env, discovered, err := snapshotformat.Decode(snapshot.Spec.RawData)
+	if err != nil {
+		return res, fmt.Errorf("failed to decode snapshot rawData: %w", err)
+	}
+	res.logf("Decoded %d devices from %s payload", len(discovered), env.Format)
+	res.provenance = env.Provenance
+	if p := env.Provenance; p != nil {
+		collector := strings.TrimSpace(p.Collector + " " + p.CollectorVersion)
+		if collector == "" {
+			collector = "an unnamed collector"
+		}
+		res.logf("Collected from %s by %s between %s and %s",
+			p.Source, collector, p.StartedAt.Format(time.RFC3339), p.CompletedAt.Format(time.RFC3339))
+		for _, w := range p.Warnings {
+			res.logf("Collection warning: %s", w.Message)
+		}
+	}
+
+	existing, err := r.loadDevices(ctx)
+	if err != nil {
+		return res, transient(err)
+	}
+
+	source := snapshotSource(snapshot.Spec.Source, env, discovered)
+	index := newDeviceIndex(r.matchKeys(), existing)
+
+	// Parents outside this snapshot are found by Redfish URI, which is only unique per BMC.
+	byURI := make(map[string]*device.Device, len(existing))
+	for _, dev := range existing {
+		key := scopedURI(snapshotformat.StringProperty(&dev.Status, propDiscoverySource), snapshotformat.StringProperty(&dev.Status, propRedfishURI))
+		if key != "" {
+			byURI[key] = dev
+		}
+	}
+
+	// 2. Match or create a Device for every discovered entry.
+	// Parents are resolved in a second pass, once every UID in the snapshot is known.
+	snapshot.Status.AmbiguousMatches = nil
+	claimed := make(map[string]bool, len(discovered))
+	// seen holds every existing Device this snapshot accounted for, including
+	// ambiguous candidates, which must not be mistaken for removed hardware.
+	seen := make(map[string]bool, len(discovered))
+	writes := make([]*pendingWrite, 0, len(discovered))
+	byRef := make(map[string]*device.Device, len(discovered))
+	for _, entry := range discovered {
+		status := entry.Status
+		if status == nil {
+			continue
+		}
+		snapshotformat.SetStringProperty(status, propDiscoverySource, source)
+
+		dev, key, candidates := index.match(status, source)
+		reason := "matched several devices"
+		if dev != nil && claimed[dev.GetUID()] {
+			// Two entries in one snapshot resolved to the same Device.
+			candidates = []string{dev.GetUID()}
+			reason = "matched a device already claimed by another entry in this snapshot"
+			dev = nil
+		}
+		if len(candidates) > 0 {
+			for _, uid := range candidates {
+				seen[uid] = true
+			}
+			snapshot.Status.AmbiguousMatches = append(snapshot.Status.AmbiguousMatches, discoverysnapshot.AmbiguousMatch{
+				Device:     deviceName(status),
+				MatchKey:   string(key),
+				Candidates: candidates,
+			})
+			skipErr := fmt.Errorf("%s %s (%s)", key, reason, strings.Join(candidates, ", "))
+			res.record("", deviceName(status), discoverysnapshot.ActionFailed, skipErr)
+			res.logf("Warning: skipped %s, %v", deviceName(status), skipErr)
+			continue
+		}
+
+		write := &pendingWrite{dev: dev, action: discoverysnapshot.ActionUpdated, parentRef: entry.ParentRef}
+		if dev == nil {
+			dev, err = newDevice(status)
+			if err != nil {
+				return res, err
+			}
+			write.dev = dev
+			write.action = discoverysnapshot.ActionCreated
+		} else {
+			write.before, _ = json.Marshal(dev.Status)
+			if dev.Status.DeletedAt != nil {
+				write.note = fmt.Sprintf(", restored after removal at %s", dev.Status.DeletedAt.Format(time.RFC3339))
+			} else {
+				write.note = fmt.Sprintf(" by %s", key)
+			}
+		}
+		mergeDeviceStatus(dev, status)
+		index.add(dev)
+		claimed[dev.GetUID()] = true
+		seen[dev.GetUID()] = true
+		if entry.Ref != "" {
+			byRef[entry.Ref] = dev
+		}
+		writes = append(writes, write)
+	}
+
+	// 3. Resolve parent references into a real ParentID.
+	for _, write := range writes {
+		if write.parentRef == "" {
+			continue
+		}
+		parent, ok := byRef[write.parentRef]
+		if !ok {
+			parent, ok = byURI[scopedURI(source, write.parentRef)]
+		}
+		if ok {
+			write.dev.Status.ParentID = parent.GetUID()
+		} else {
+			res.logf("Warning: parent %s of %s not found", write.parentRef, write.dev.GetUID())
+		}
+	}
+
+	// 4. Mark hardware that vanished from the systems this snapshot covered.
+	// A collection that reported warnings may simply have failed to read the
+	// missing devices, so it never removes anything.
+	roots := scopeRoots(discovered)
+	if env.Provenance != nil && !env.Provenance.Complete() {
+		res.logf("Warning: collection reported %d warnings, not marking unseen devices as removed", len(env.Provenance.Warnings))
+		roots = nil
+	}
+	now := time.Now()
+	for _, dev := range existing {
+		if seen[dev.GetUID()] || dev.Status.DeletedAt != nil || !inSnapshotScope(dev, source, roots) {
+			continue
+		}
+		dev.Status.DeletedAt = &now
+		writes = append(writes, &pendingWrite{
+			dev:    dev,
+			action: discoverysnapshot.ActionRemoved,
+			note:   fmt.Sprintf(", no longer reported by %s", source),
+		})
+	}
+
+	// 5. Stage everything that changed and commit it in one go, so a failure
+	// never leaves part of a node updated and the rest stale.
+	changed := make([]*pendingWrite, 0, len(writes))
+	for _, write := range writes {
+		if write.before != nil {
+			if after, _ := json.Marshal(write.dev.Status); bytes.Equal(write.before, after) {
+				res.record(write.dev.GetUID(), write.dev.GetName(), discoverysnapshot.ActionUnchanged, nil)
+				continue
+			}
+		}
+		changed = append(changed, write)
+	}
+
+	tx := storage.Begin(r.Storage)
+	for _, write := range changed {
+		write.dev.Touch()
+		if err = stageDevice(ctx, tx, write.dev); err != nil {
+			tx.Rollback()
+			break
+		}
+	}
+	if err == nil {
+		if err = tx.Commit(ctx); err != nil {
+			err = transient(err)
+		}
+	}
+	if err != nil {
+		for _, write := range changed {
+			res.record(write.dev.GetUID(), write.dev.GetName(), discoverysnapshot.ActionFailed, err)
+		}
+		res.logf("Error: %v", err)
+		return res, fmt.Errorf("no devices were changed: %w", err)
+	}
+
+	for _, write := range changed {
+		dev := write.dev
+		res.record(dev.GetUID(), dev.GetName(), write.action, nil)
+		res.logf("%s %s %s (%s)%s", write.action, dev.Status.DeviceType, dev.GetName(), dev.GetUID(), write.note)
+	}
+	return res, nil
+}
+
+// scopeRootTypes are the device types of top-level Redfish resources (members
+// of /Systems, /Chassis and /Managers); their URIs bound a snapshot's scope.
+var scopeRootTypes = map[string]bool{"Node": true, "Chassis": true, "Rack": true, "Blade": true, "BMC": true}
+
+// scopeRoots returns the Redfish URIs of the systems, chassis and managers a
+// snapshot walked. Only hardware under these can be judged missing; a system
+// the collector never reached says nothing about its components.
+func scopeRoots(discovered []snapshotformat.Entry) []string {
+	var roots []string
+	for _, entry := range discovered {
+		status := entry.Status
+		if status == nil || !scopeRootTypes[status.DeviceType] {
+			continue
+		}
+		if uri := snapshotformat.StringProperty(status, propRedfishURI); uri != "" {
+			roots = append(roots, uri)
+		}
+	}
+	return roots
+}
+
+// inSnapshotScope reports whether an existing Device came from the same source
+// and sits at or below one of the snapshot's scope roots.
+func inSnapshotScope(dev *device.Device, source string, roots []string) bool {
+	if source == "" || snapshotformat.StringProperty(&dev.Status, propDiscoverySource) != source {
+		return false
+	}
+	uri := snapshotformat.StringProperty(&dev.Status, propRedfishURI)
+	for _, root := range roots {
+		if uri == root || strings.HasPrefix(uri, root+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchKeys returns the configured identity rules, falling back to the defaults.
+func (r *DiscoverySnapshotReconciler) matchKeys() []MatchKey {
+	if len(r.MatchKeys) == 0 {
+		return DefaultMatchKeys
+	}
+	return r.MatchKeys
+}
+
+// newDevice builds a fresh Device envelope for a discovered status.
+func newDevice(status *device.DeviceStatus) (*device.Device, error) {
+	uid, err := resource.GenerateUIDForResource(deviceKind)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate Device UID: %w", err)
+	}
+	dev := &device.Device{
+		Resource: resource.Resource{
+			APIVersion:    "v1",
+			Kind:          deviceKind,
+			SchemaVersion: "v1",
+		},
+	}
+	dev.Metadata.Initialize(deviceName(status), uid)
+	return dev, nil
+}
+
+// mergeDeviceStatus copies discovered fields onto a Device. Properties are merged
+// so attributes added by other tools survive a re-discovery.
+func mergeDeviceStatus(dev *device.Device, status *device.DeviceStatus) {
+	dev.Status.DeviceType = status.DeviceType
+	dev.Status.Manufacturer = status.Manufacturer
+	dev.Status.PartNumber = status.PartNumber
+	dev.Status.SerialNumber = status.SerialNumber
+	dev.Status.DeletedAt = nil // Seen again, so no longer removed
+	if status.SchemaVersion != "" {
+		dev.Status.SchemaVersion = status.SchemaVersion
+	}
+	if dev.Status.Properties == nil {
+		dev.Status.Properties = make(map[string]json.RawMessage, len(status.Properties))
+	}
+	for k, v := range status.Properties {
+		dev.Status.Properties[k] = v
+	}
+}
+
+// deviceName follows the collector's naming: <Type>-<Serial>, or the Redfish URI
+// (or discovery_ref) with slashes replaced when there is no serial number.
+func deviceName(status *device.DeviceStatus) string {
+	if status.SerialNumber != "" {
+		return fmt.Sprintf("%s-%s", status.DeviceType, status.SerialNumber)
+	}
+	uri := snapshotformat.StringProperty(status, propRedfishURI)
+	if uri == "" {
+		uri = snapshotformat.StringProperty(status, snapshotformat.PropDiscoveryRef)
+	}
+	return fmt.Sprintf("%s-%s", status.DeviceType, strings.ReplaceAll(uri, "/", "-"))
+}
@@ -3,14 +3,12 @@ package reconcilers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"time" // <<< FIX: Import the time package
+	"time"
 
 	"github.com/openchami/fabrica/pkg/reconcile"
 	"github.com/openchami/fabrica/pkg/storage"
 
-	// Import your resource definition
 	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
 )
 
@@ -18,6 +16,17 @@ import (
 type DiscoverySnapshotReconciler struct {
 	reconcile.BaseReconciler
 	Storage storage.StorageBackend
+
+	// MatchKeys is the ordered list of identity rules used to recognise existing
+	// Devices. Empty means DefaultMatchKeys.
+	MatchKeys []MatchKey
+
+	// MaxAttempts caps how many times a snapshot is processed before a transient
+	// error is treated as final. Zero means DefaultMaxAttempts.
+	MaxAttempts int
+	// RetryBaseDelay is the first backoff after a transient error; it doubles on
+	// each further attempt. Zero means DefaultRetryBaseDelay.
+	RetryBaseDelay time.Duration
 }
 
 // GetResourceKind returns the resource kind "DiscoverySnapshot"
@@ -27,87 +36,124 @@ func (r *DiscoverySnapshotReconciler) GetResourceKind() string {
 }
 
 // Reconcile is the core logic. It's triggered when a DiscoverySnapshot is created or updated.
+//
+// A run moves the snapshot through the lifecycle in lifecycle.go: Pending ->
+// Processing -> Complete or Error (or back to Pending for a transient retry).
+// Snapshots in any other phase are left alone, so re-delivered events are harmless.
 func (r *DiscoverySnapshotReconciler) Reconcile(ctx context.Context, resource interface{}) (reconcile.Result, error) {
-	// Cast the resource to our specific type
-	snapshot, ok := resource.(*discoverysnapshot.DiscoverySnapshot)
-	if !ok {
-		return reconcile.Result{}, fmt.Errorf("invalid resource type, expected *DiscoverySnapshot, got %T", resource)
+	// Cast the resource to our specific type (the controller hands us raw JSON)
+	snapshot, err := toDiscoverySnapshot(resource)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
 	// Use the logger from BaseReconciler
-	r.Logger.Infof("Reconciling DiscoverySnapshot %s (Phase: %s)", snapshot.GetUID(), snapshot.Status.Phase)
+	phase := snapshot.Status.CurrentPhase()
+	r.Logger.Infof("Reconciling DiscoverySnapshot %s (Phase: %s)", snapshot.GetUID(), phase)
 
 	// --- IDEMPOTENCY CHECK ---
-	if snapshot.Status.Phase == "Complete" || snapshot.Status.Phase == "Error" {
+	switch phase {
+	case discoverysnapshot.PhasePending:
+	case discoverysnapshot.PhaseProcessing:
+		// The work queue never hands us the same snapshot twice at once, so this
+		// is an attempt that was cut short (e.g. by a restart).
+		r.Logger.Warnf("Snapshot %s was left in Processing, resuming.", snapshot.GetUID())
+	default:
 		r.Logger.Infof("Snapshot %s already processed. Skipping.", snapshot.GetUID())
 		return reconcile.Result{}, nil
 	}
 
-	// <<< FIX: Handle the initial "create" event race condition >>>
-	// If the phase is empty, it means the HTTP handler just created it.
-	// Set it to "Pending" and requeue to let the HTTP handler finish.
-	if snapshot.Status.Phase == "" {
-		r.Logger.Infof("New snapshot %s detected, setting to Pending and requeueing.", snapshot.GetUID())
-		snapshot.Status.Phase = "Pending"
-		snapshot.Status.Message = "Snapshot queued for processing."
-		snapshotData, err := json.Marshal(snapshot)
-		if err != nil {
-			r.Logger.Errorf("Failed to marshal snapshot %s for Pending update: %v", snapshot.GetUID(), err)
-			return reconcile.Result{}, err
-		}
-		if err := r.Storage.Save(ctx, r.GetResourceKind(), snapshot.GetUID(), snapshotData); err != nil {
-			r.Logger.Errorf("Failed to update status to Pending for %s: %v", snapshot.GetUID(), err)
-			return reconcile.Result{}, err // Return error, will retry
-		}
-		// Requeue with a small delay
-		return reconcile.Result{Requeue: true, RequeueAfter: 1 * time.Second}, nil
-	}
-	// <<< END FIX >>>
-
-	// --- START PROCESSING (Phase will be "Pending" here) ---
-	snapshot.Status.Phase = "Processing"
-	snapshot.Status.Message = "Reconciliation started."
-
-	snapshotData, err := json.Marshal(snapshot)
-	if err != nil {
-		r.Logger.Errorf("Failed to marshal snapshot %s for saving: %v", snapshot.GetUID(), err)
-		return reconcile.Result{}, err // Return error, will retry
+	// --- START PROCESSING ---
+	startedAt := time.Now()
+	if err := snapshot.Status.SetPhase(discoverysnapshot.PhaseProcessing, "Reconciliation started."); err != nil {
+		return reconcile.Result{}, err
 	}
-	if err := r.Storage.Save(ctx, r.GetResourceKind(), snapshot.GetUID(), snapshotData); err != nil {
+	snapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonProcessing, "Applying snapshot to the device inventory.")
+	snapshot.Status.Attempts++
+	snapshot.Status.StartedAt = &startedAt
+	snapshot.Status.CompletedAt = nil
+	snapshot.Status.Summary = nil
+	snapshot.Status.Results = nil
+	if err := r.UpdateStatus(ctx, snapshot); err != nil {
 		r.Logger.Errorf("Failed to update status to Processing for %s: %v", snapshot.GetUID(), err)
 		return reconcile.Result{}, err // Return error for retry
 	}
 
-	//
-	// --- TODO: OUR CORE LOGIC GOES HERE ---
-	//
-	// 1. Unmarshal `snapshot.Spec.RawData`
-	// 2. Loop through devices in the raw data
-	// 3. Use `r.Storage` to Create/Update the *actual* Device resources
-	//    (e.g., r.Storage.Save(ctx, "Device", newDevice.GetUID(), newDevice))
-	// 4. Log successes/failures to `snapshot.Status.Logs`
-	//
-
-	// For now, we'll just log and set it to "Complete"
-	r.Logger.Infof("TODO: Implement snapshot processing logic for %s", snapshot.GetUID())
-	snapshot.Status.Logs = append(snapshot.Status.Logs, "Snapshot processed successfully (stub).")
+	// --- CORE LOGIC: turn the snapshot into Device resources ---
+	applied, applyErr := r.applySnapshot(ctx, snapshot)
+	snapshot.Status.Logs = append(snapshot.Status.Logs, applied.logs...)
+	snapshot.Status.Summary = &applied.summary
+	snapshot.Status.Results = applied.results
+	snapshot.Status.Provenance = applied.provenance
+	switch p := applied.provenance; {
+	case p == nil:
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionCollectionComplete, "Unknown", discoverysnapshot.ReasonNoProvenance, "The payload does not describe how it was collected.")
+	case !p.Complete():
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionCollectionComplete, "False", discoverysnapshot.ReasonCollectionWarnings,
+			fmt.Sprintf("The collector could not read %d endpoints; unseen devices were not removed.", len(p.Warnings)))
+	default:
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionCollectionComplete, "True", discoverysnapshot.ReasonNoWarnings, "Every endpoint was read.")
+	}
+	if len(snapshot.Status.AmbiguousMatches) > 0 {
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionDevicesMatched, "False", discoverysnapshot.ReasonAmbiguousMatches,
+			fmt.Sprintf("%d entries matched more than one device and were skipped.", len(snapshot.Status.AmbiguousMatches)))
+	} else if applyErr == nil {
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionDevicesMatched, "True", discoverysnapshot.ReasonAllMatched, "Every entry resolved to a single device.")
+	}
 
 	// --- FINISH PROCESSING ---
-	snapshot.Status.Phase = "Complete"
-	snapshot.Status.Message = "Snapshot processed successfully."
-
-	finalSnapshotData, err := json.Marshal(snapshot)
+	completedAt := time.Now()
+	snapshot.Status.CompletedAt = &completedAt
+
+	var result reconcile.Result
+	switch {
+	case isTransient(applyErr) && snapshot.Status.Attempts < r.maxAttempts():
+		delay := retryDelay(r.retryBaseDelay(), snapshot.Status.Attempts)
+		r.Logger.Warnf("Attempt %d for snapshot %s failed, retrying in %s: %v", snapshot.Status.Attempts, snapshot.GetUID(), delay, applyErr)
+		err = snapshot.Status.SetPhase(discoverysnapshot.PhasePending,
+			fmt.Sprintf("Attempt %d of %d failed, retrying in %s: %v", snapshot.Status.Attempts, r.maxAttempts(), delay, applyErr))
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonTransientError, applyErr.Error())
+		result.RequeueAfter = delay
+	case isTransient(applyErr):
+		r.Logger.Errorf("Failed to apply snapshot %s: %v", snapshot.GetUID(), applyErr)
+		err = snapshot.Status.SetPhase(discoverysnapshot.PhaseError,
+			fmt.Sprintf("%v (gave up after %d attempts)", applyErr, snapshot.Status.Attempts))
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonRetriesExhausted, applyErr.Error())
+	case applyErr != nil:
+		r.Logger.Errorf("Failed to apply snapshot %s: %v", snapshot.GetUID(), applyErr)
+		err = snapshot.Status.SetPhase(discoverysnapshot.PhaseError, applyErr.Error())
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "False", discoverysnapshot.ReasonApplyFailed, applyErr.Error())
+	default:
+		err = snapshot.Status.SetPhase(discoverysnapshot.PhaseComplete, fmt.Sprintf("Snapshot processed successfully: %s.", applied))
+		snapshot.Status.SetCondition(discoverysnapshot.ConditionProcessed, "True", discoverysnapshot.ReasonApplied, applied.String())
+	}
 	if err != nil {
-		r.Logger.Errorf("Failed to marshal final snapshot %s for saving: %v", snapshot.GetUID(), err)
 		return reconcile.Result{}, err
 	}
-	if err := r.Storage.Save(ctx, r.GetResourceKind(), snapshot.GetUID(), finalSnapshotData); err != nil {
-		r.Logger.Errorf("Failed to update status to Complete for %s: %v", snapshot.GetUID(), err)
+
+	if err := r.UpdateStatus(ctx, snapshot); err != nil {
+		r.Logger.Errorf("Failed to update status to %s for %s: %v", snapshot.Status.Phase, snapshot.GetUID(), err)
 		return reconcile.Result{}, err
 	}
 
 	r.Logger.Infof("Successfully reconciled DiscoverySnapshot %s", snapshot.GetUID())
 
-	// We are done, no need to requeue
-	return reconcile.Result{}, nil
-}
\ No newline at end of file
+	// Only a pending retry asks to be requeued
+	return result, nil
+}
+
+// maxAttempts returns the configured attempt cap, falling back to the default.
+func (r *DiscoverySnapshotReconciler) maxAttempts() int {
+	if r.MaxAttempts <= 0 {
+		return DefaultMaxAttempts
+	}
+	return r.MaxAttempts
+}
+
+// retryBaseDelay returns the configured first backoff, falling back to the default.
+func (r *DiscoverySnapshotReconciler) retryBaseDelay() time.Duration {
+	if r.RetryBaseDelay <= 0 {
+		return DefaultRetryBaseDelay
+	}
+	return r.RetryBaseDelay
+}
@@ -0,0 +1,774 @@
+package reconcilers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openchami/fabrica/pkg/reconcile"
+	"github.com/openchami/fabrica/pkg/resource"
+	fabricaStorage "github.com/openchami/fabrica/pkg/storage"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// --- Test Fixtures ---
+
+// entry is one device in a test payload, as the collector would send it.
+type entry struct {
+	deviceType, manufacturer, serial, uri, parentURI string
+}
+
+func (e entry) status() *device.DeviceStatus {
+	status := &device.DeviceStatus{
+		DeviceType:   e.deviceType,
+		Manufacturer: e.manufacturer,
+		SerialNumber: e.serial,
+		Properties:   map[string]json.RawMessage{},
+	}
+	snapshotformat.SetStringProperty(status, propRedfishURI, e.uri)
+	snapshotformat.SetStringProperty(status, "redfish_parent_uri", e.parentURI)
+	return status
+}
+
+// payload is one snapshot to reconcile.
+type payload struct {
+	source         string
+	entries        []entry
+	warnings       int             // Collection warnings in the provenance
+	bare           bool            // Post a bare array, with no provenance
+	provenanceOnly bool            // Leave spec.source empty; the source is only in the provenance
+	raw            json.RawMessage // Device list to send instead of entries
+}
+
+func (p payload) rawData(t *testing.T) json.RawMessage {
+	t.Helper()
+	statuses := make([]*device.DeviceStatus, 0, len(p.entries))
+	for _, e := range p.entries {
+		statuses = append(statuses, e.status())
+	}
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.raw != nil {
+		data = p.raw
+	}
+	if p.bare {
+		return data
+	}
+	provenance := &snapshotformat.Provenance{Collector: "test", Source: p.source, StartedAt: time.Now(), CompletedAt: time.Now()}
+	for i := 0; i < p.warnings; i++ {
+		provenance.Warnings = append(provenance.Warnings, snapshotformat.Warning{URI: "/Systems/1/Memory", Message: "unreadable"})
+	}
+	raw, err := json.Marshal(snapshotformat.Envelope{Format: snapshotformat.FormatRedfishCollectorV1, Provenance: provenance, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+// quietLogger drops the reconciler's log lines.
+type quietLogger struct{}
+
+func (quietLogger) Infof(string, ...interface{})  {}
+func (quietLogger) Warnf(string, ...interface{})  {}
+func (quietLogger) Errorf(string, ...interface{}) {}
+func (quietLogger) Debugf(string, ...interface{}) {}
+
+// flakyBackend lets the first passSaves Device writes through, then fails the
+// next failSaves of them (-1 fails every one).
+type flakyBackend struct {
+	fabricaStorage.StorageBackend
+	passSaves int
+	failSaves int
+}
+
+func (b *flakyBackend) Save(ctx context.Context, kind, uid string, data json.RawMessage) error {
+	if kind == deviceKind && b.failSaves != 0 {
+		if b.passSaves > 0 {
+			b.passSaves--
+		} else {
+			b.failSaves--
+			return errors.New("disk full")
+		}
+	}
+	return b.StorageBackend.Save(ctx, kind, uid, data)
+}
+
+// newTestReconciler returns a reconciler over an empty file backend in a
+// temporary directory. storage.Init is global, so tests must not run in parallel.
+func newTestReconciler(t *testing.T) (*DiscoverySnapshotReconciler, *flakyBackend) {
+	t.Helper()
+	files, err := fabricaStorage.NewFileBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := &flakyBackend{StorageBackend: files}
+	storage.Init(backend)
+	r := &DiscoverySnapshotReconciler{
+		BaseReconciler: reconcile.BaseReconciler{Client: storage.NewStorageClient(), Logger: quietLogger{}},
+		Storage:        backend,
+		MaxAttempts:    3,
+		RetryBaseDelay: time.Millisecond,
+	}
+	return r, backend
+}
+
+// post saves a snapshot the way the API does and returns it.
+func post(t *testing.T, p payload) *discoverysnapshot.DiscoverySnapshot {
+	t.Helper()
+	if p.provenanceOnly {
+		return postRaw(t, "", p.rawData(t))
+	}
+	return postRaw(t, p.source, p.rawData(t))
+}
+
+// postRaw saves a snapshot with a payload built by the caller.
+func postRaw(t *testing.T, source string, rawData json.RawMessage) *discoverysnapshot.DiscoverySnapshot {
+	t.Helper()
+	uid, err := resource.GenerateUIDForResource("DiscoverySnapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := &discoverysnapshot.DiscoverySnapshot{
+		Resource: resource.Resource{APIVersion: "v1", Kind: "DiscoverySnapshot"},
+		Spec:     discoverysnapshot.DiscoverySnapshotSpec{Source: source, RawData: rawData},
+	}
+	snapshot.Metadata.Initialize("snapshot-"+uid, uid)
+	if err := storage.SaveDiscoverySnapshot(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+// reconcileOnce runs one reconcile of a stored snapshot and returns it as saved.
+func reconcileOnce(t *testing.T, r *DiscoverySnapshotReconciler, uid string) (*discoverysnapshot.DiscoverySnapshot, reconcile.Result) {
+	t.Helper()
+	ctx := context.Background()
+	snapshot, err := storage.LoadDiscoverySnapshot(ctx, uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.Reconcile(ctx, snapshot)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if snapshot, err = storage.LoadDiscoverySnapshot(ctx, uid); err != nil {
+		t.Fatal(err)
+	}
+	return snapshot, result
+}
+
+// ingest posts and reconciles each payload in turn, failing unless each completes.
+func ingest(t *testing.T, r *DiscoverySnapshotReconciler, payloads ...payload) *discoverysnapshot.DiscoverySnapshot {
+	t.Helper()
+	var snapshot *discoverysnapshot.DiscoverySnapshot
+	for _, p := range payloads {
+		snapshot, _ = reconcileOnce(t, r, post(t, p).GetUID())
+		if phase := snapshot.Status.CurrentPhase(); phase != discoverysnapshot.PhaseComplete {
+			t.Fatalf("snapshot phase = %s (%s), want Complete", phase, snapshot.Status.Message)
+		}
+	}
+	return snapshot
+}
+
+func loadDevices(t *testing.T) []*device.Device {
+	t.Helper()
+	devices, err := storage.LoadAllDevices(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return devices
+}
+
+// deviceAt returns the device a source reported at a Redfish URI.
+func deviceAt(t *testing.T, source, uri string) *device.Device {
+	t.Helper()
+	for _, dev := range loadDevices(t) {
+		if snapshotformat.StringProperty(&dev.Status, propDiscoverySource) == source && snapshotformat.StringProperty(&dev.Status, propRedfishURI) == uri {
+			return dev
+		}
+	}
+	t.Fatalf("no device for %s at %s", source, uri)
+	return nil
+}
+
+// --- Tests ---
+
+func TestReconcileIdentity(t *testing.T) {
+	node := entry{"Node", "Contoso", "SN1", "/Systems/1", ""}
+	tests := []struct {
+		name     string
+		payloads []payload
+		devices  int
+		want     [2]string                       // Source and Redfish URI the node is recorded at afterwards
+		summary  discoverysnapshot.IngestSummary // Of the last payload
+	}{
+		{
+			name:     "new device",
+			payloads: []payload{{source: "10.0.0.1", entries: []entry{node}}},
+			devices:  1,
+			want:     [2]string{"10.0.0.1", "/Systems/1"},
+			summary:  discoverysnapshot.IngestSummary{Created: 1},
+		},
+		{
+			name: "same snapshot again",
+			payloads: []payload{
+				{source: "10.0.0.1", entries: []entry{node}},
+				{source: "10.0.0.1", entries: []entry{node}},
+			},
+			devices: 1,
+			want:    [2]string{"10.0.0.1", "/Systems/1"},
+			summary: discoverysnapshot.IngestSummary{Unchanged: 1},
+		},
+		{
+			name: "serial moves to another BMC",
+			payloads: []payload{
+				{source: "10.0.0.1", entries: []entry{node}},
+				{source: "10.0.0.2", entries: []entry{{"Node", "Contoso", "SN1", "/Systems/Node7", ""}}},
+			},
+			devices: 1,
+			want:    [2]string{"10.0.0.2", "/Systems/Node7"},
+			summary: discoverysnapshot.IngestSummary{Updated: 1},
+		},
+		{
+			name: "replaced serial at the same URI",
+			payloads: []payload{
+				{source: "10.0.0.1", entries: []entry{node}},
+				{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "SN2", "/Systems/1", ""}}},
+			},
+			devices: 1,
+			want:    [2]string{"10.0.0.1", "/Systems/1"},
+			summary: discoverysnapshot.IngestSummary{Updated: 1},
+		},
+		{
+			name: "same URI on two BMCs",
+			payloads: []payload{
+				{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "N/A", "/Systems/1", ""}}},
+				{source: "10.0.0.2", entries: []entry{{"Node", "Contoso", "N/A", "/Systems/1", ""}}},
+			},
+			devices: 2,
+			want:    [2]string{"10.0.0.2", "/Systems/1"},
+			summary: discoverysnapshot.IngestSummary{Created: 1},
+		},
+		{
+			// Without a source the URI could belong to any BMC
+			name: "same URI without a source",
+			payloads: []payload{
+				{bare: true, entries: []entry{{"Node", "Contoso", "N/A", "/Systems/1", ""}}},
+				{bare: true, entries: []entry{{"Node", "Contoso", "N/A", "/Systems/1", ""}}},
+			},
+			devices: 2,
+			want:    [2]string{"", "/Systems/1"},
+			summary: discoverysnapshot.IngestSummary{Created: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestReconciler(t)
+			snapshot := ingest(t, r, tt.payloads...)
+			if got := len(loadDevices(t)); got != tt.devices {
+				t.Errorf("devices = %d, want %d", got, tt.devices)
+			}
+			deviceAt(t, tt.want[0], tt.want[1])
+			if *snapshot.Status.Summary != tt.summary {
+				t.Errorf("summary = %+v, want %+v", *snapshot.Status.Summary, tt.summary)
+			}
+		})
+	}
+}
+
+func TestReconcileParents(t *testing.T) {
+	r, _ := newTestReconciler(t)
+	ingest(t, r, payload{source: "10.0.0.1", entries: []entry{
+		{"Rack", "Contoso", "R1", "/Chassis/Rack1", ""},
+		{"Node", "Contoso", "SN1", "/Systems/1", "/Chassis/Rack1"},
+	}})
+	// A later snapshot may hold only the child; its parent is found in storage
+	ingest(t, r, payload{source: "10.0.0.1", entries: []entry{
+		{"CPU", "Contoso", "CPU1", "/Systems/1/Processors/CPU1", "/Systems/1"},
+	}})
+	// The same URIs on another BMC belong to another node
+	ingest(t, r, payload{source: "10.0.0.2", entries: []entry{
+		{"Node", "Contoso", "SN2", "/Systems/1", ""},
+		{"CPU", "Contoso", "CPU2", "/Systems/1/Processors/CPU1", "/Systems/1"},
+	}})
+
+	rack := deviceAt(t, "10.0.0.1", "/Chassis/Rack1")
+	node := deviceAt(t, "10.0.0.1", "/Systems/1")
+	cpu := deviceAt(t, "10.0.0.1", "/Systems/1/Processors/CPU1")
+	if node.Status.ParentID != rack.GetUID() {
+		t.Errorf("node parent = %q, want the rack %q", node.Status.ParentID, rack.GetUID())
+	}
+	if cpu.Status.ParentID != node.GetUID() {
+		t.Errorf("CPU parent = %q, want the node %q", cpu.Status.ParentID, node.GetUID())
+	}
+	if rack.Status.ParentID != "" {
+		t.Errorf("rack parent = %q, want none", rack.Status.ParentID)
+	}
+	otherNode := deviceAt(t, "10.0.0.2", "/Systems/1")
+	if otherCPU := deviceAt(t, "10.0.0.2", "/Systems/1/Processors/CPU1"); otherCPU.Status.ParentID != otherNode.GetUID() {
+		t.Errorf("CPU on 10.0.0.2 parent = %q, want its own node %q", otherCPU.Status.ParentID, otherNode.GetUID())
+	}
+}
+
+func TestReconcileHPCMNode(t *testing.T) {
+	data, err := os.ReadFile("../../test-data/node_data.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(snapshotformat.Envelope{Format: snapshotformat.FormatHPCMNodeV1, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := newTestReconciler(t)
+	var snapshots []*discoverysnapshot.DiscoverySnapshot
+	for i := 0; i < 2; i++ {
+		snapshot, _ := reconcileOnce(t, r, postRaw(t, "hpcm", raw).GetUID())
+		if phase := snapshot.Status.CurrentPhase(); phase != discoverysnapshot.PhaseComplete {
+			t.Fatalf("snapshot phase = %s (%s), want Complete", phase, snapshot.Status.Message)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if want := (discoverysnapshot.IngestSummary{Created: 8}); *snapshots[0].Status.Summary != want {
+		t.Errorf("first summary = %+v, want %+v", *snapshots[0].Status.Summary, want)
+	}
+	if want := (discoverysnapshot.IngestSummary{Unchanged: 8}); *snapshots[1].Status.Summary != want {
+		t.Errorf("second summary = %+v, want %+v", *snapshots[1].Status.Summary, want)
+	}
+
+	devices := loadDevices(t)
+	var node *device.Device
+	for _, dev := range devices {
+		if dev.Status.DeviceType == "Node" {
+			node = dev
+		}
+	}
+	if node == nil {
+		t.Fatal("no Node device")
+	}
+	for _, dev := range devices {
+		if dev != node && dev.Status.ParentID != node.GetUID() {
+			t.Errorf("%s parent = %q, want the node", dev.GetName(), dev.Status.ParentID)
+		}
+	}
+}
+
+func TestReconcileWithoutSource(t *testing.T) {
+	// Each payload has a DIMM without a serial number, which only its
+	// discovery_ref can match again.
+	tests := []struct {
+		format string
+		data   string
+		source string // The node's discovery_ref
+	}{
+		{
+			snapshotformat.FormatHPCMNodeV1,
+			`{"name": "n1", "uuid": "u1", "inventory": {"sys.Manufacturer": "Contoso", "sys.Serial Number": "SN1", "dimm.A.Manufacturer": "Contoso"}}`,
+			"nodes/u1",
+		},
+		{
+			snapshotformat.FormatLSHWV1,
+			`{"id": "node01", "class": "system", "vendor": "Contoso", "serial": "SN1", "children": [{"id": "bank:0", "class": "memory", "vendor": "Contoso"}]}`,
+			"node01",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			raw, err := json.Marshal(snapshotformat.Envelope{Format: tt.format, Data: json.RawMessage(tt.data)})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			r, _ := newTestReconciler(t)
+			first, _ := reconcileOnce(t, r, postRaw(t, "", raw).GetUID())
+			second, _ := reconcileOnce(t, r, postRaw(t, "", raw).GetUID())
+			if want := (discoverysnapshot.IngestSummary{Created: 2}); *first.Status.Summary != want {
+				t.Errorf("first summary = %+v, want %+v", *first.Status.Summary, want)
+			}
+			if want := (discoverysnapshot.IngestSummary{Unchanged: 2}); *second.Status.Summary != want {
+				t.Errorf("second summary = %+v, want %+v", *second.Status.Summary, want)
+			}
+			devices := loadDevices(t)
+			if len(devices) != 2 {
+				t.Errorf("%d devices after re-ingest, want 2", len(devices))
+			}
+			for _, dev := range devices {
+				if got := snapshotformat.StringProperty(&dev.Status, propDiscoverySource); got != tt.source {
+					t.Errorf("%s discovery_source = %q, want %q", dev.GetName(), got, tt.source)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileProvenance(t *testing.T) {
+	node := []entry{{"Node", "Contoso", "SN1", "/Systems/1", ""}}
+	tests := []struct {
+		name    string
+		payload payload
+		reason  string // CollectionComplete condition reason
+	}{
+		{"complete collection", payload{source: "10.0.0.1", entries: node}, discoverysnapshot.ReasonNoWarnings},
+		{"collection with warnings", payload{source: "10.0.0.1", entries: node, warnings: 2}, discoverysnapshot.ReasonCollectionWarnings},
+		{"no provenance", payload{source: "10.0.0.1", entries: node, bare: true}, discoverysnapshot.ReasonNoProvenance},
+		{"source only in the provenance", payload{source: "10.0.0.1", entries: node, provenanceOnly: true}, discoverysnapshot.ReasonNoWarnings},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestReconciler(t)
+			snapshot := ingest(t, r, tt.payload)
+			if got := conditionReason(snapshot, discoverysnapshot.ConditionCollectionComplete); got != tt.reason {
+				t.Errorf("CollectionComplete reason = %s, want %s", got, tt.reason)
+			}
+			if tt.payload.bare != (snapshot.Status.Provenance == nil) {
+				t.Errorf("status.provenance = %+v", snapshot.Status.Provenance)
+			}
+			deviceAt(t, "10.0.0.1", "/Systems/1")
+		})
+	}
+}
+
+func TestReconcileRemoval(t *testing.T) {
+	full := payload{source: "10.0.0.1", entries: []entry{
+		{"Node", "Contoso", "SN1", "/Systems/1", ""},
+		{"CPU", "Contoso", "CPU1", "/Systems/1/Processors/CPU1", "/Systems/1"},
+		{"CPU", "Contoso", "CPU2", "/Systems/1/Processors/CPU2", "/Systems/1"},
+	}}
+	withoutCPU2 := payload{source: "10.0.0.1", entries: full.entries[:2]}
+	withoutNode := payload{source: "10.0.0.1", entries: full.entries[1:2]}
+	withWarnings := withoutCPU2
+	withWarnings.warnings = 1
+	otherBMC := payload{source: "10.0.0.2", entries: []entry{{"Node", "Contoso", "SN9", "/Systems/1", ""}}}
+
+	tests := []struct {
+		name     string
+		payloads []payload
+		removed  []string // Redfish URIs on 10.0.0.1 marked deletedAt
+		summary  discoverysnapshot.IngestSummary
+	}{
+		{
+			name:     "missing CPU",
+			payloads: []payload{full, withoutCPU2},
+			removed:  []string{"/Systems/1/Processors/CPU2"},
+			summary:  discoverysnapshot.IngestSummary{Unchanged: 2, Removed: 1},
+		},
+		{
+			name:     "collection with warnings",
+			payloads: []payload{full, withWarnings},
+			summary:  discoverysnapshot.IngestSummary{Unchanged: 2},
+		},
+		{
+			name:     "system not walked",
+			payloads: []payload{full, withoutNode},
+			summary:  discoverysnapshot.IngestSummary{Unchanged: 1},
+		},
+		{
+			name:     "another BMC",
+			payloads: []payload{full, otherBMC},
+			summary:  discoverysnapshot.IngestSummary{Created: 1},
+		},
+		{
+			name:     "missing CPU comes back",
+			payloads: []payload{full, withoutCPU2, full},
+			summary:  discoverysnapshot.IngestSummary{Unchanged: 2, Updated: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestReconciler(t)
+			snapshot := ingest(t, r, tt.payloads...)
+			var removed []string
+			for _, dev := range loadDevices(t) {
+				if dev.Status.DeletedAt != nil {
+					removed = append(removed, snapshotformat.StringProperty(&dev.Status, propRedfishURI))
+				}
+			}
+			sort.Strings(removed)
+			if strings.Join(removed, " ") != strings.Join(tt.removed, " ") {
+				t.Errorf("removed = %v, want %v", removed, tt.removed)
+			}
+			if *snapshot.Status.Summary != tt.summary {
+				t.Errorf("summary = %+v, want %+v", *snapshot.Status.Summary, tt.summary)
+			}
+		})
+	}
+}
+
+func TestReconcileAmbiguousMatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		existing   []payload // Ingested first
+		seed       int       // Extra copies of the Contoso SN1 node saved straight to storage
+		payload    payload
+		candidates int
+		summary    discoverysnapshot.IngestSummary
+	}{
+		{
+			name:       "two stored devices share a serial",
+			seed:       2,
+			payload:    payload{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "SN1", "/Systems/1", ""}}},
+			candidates: 2,
+			summary:    discoverysnapshot.IngestSummary{Failed: 1},
+		},
+		{
+			name:     "two entries claim one device",
+			existing: []payload{{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "SN1", "/Systems/1", ""}}}},
+			payload: payload{source: "10.0.0.1", entries: []entry{
+				{"Node", "Contoso", "SN1", "/Systems/1", ""},
+				{"Node", "Contoso", "SN1", "/Systems/2", ""},
+			}},
+			candidates: 1,
+			summary:    discoverysnapshot.IngestSummary{Unchanged: 1, Failed: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestReconciler(t)
+			ingest(t, r, tt.existing...)
+			for i := 0; i < tt.seed; i++ {
+				status := entry{"Node", "Contoso", "SN1", "", ""}.status()
+				dev, err := newDevice(status)
+				if err != nil {
+					t.Fatal(err)
+				}
+				mergeDeviceStatus(dev, status)
+				if err := storage.SaveDevice(context.Background(), dev); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before := len(loadDevices(t))
+
+			snapshot := ingest(t, r, tt.payload)
+			if got := len(loadDevices(t)); got != before {
+				t.Errorf("devices = %d, want %d; an ambiguous entry must not create one", got, before)
+			}
+			if len(snapshot.Status.AmbiguousMatches) != 1 {
+				t.Fatalf("ambiguousMatches = %+v, want one", snapshot.Status.AmbiguousMatches)
+			}
+			match := snapshot.Status.AmbiguousMatches[0]
+			if match.MatchKey != string(MatchSerialManufacturer) || len(match.Candidates) != tt.candidates {
+				t.Errorf("ambiguous match = %+v, want %d %s candidates", match, tt.candidates, MatchSerialManufacturer)
+			}
+			if *snapshot.Status.Summary != tt.summary {
+				t.Errorf("summary = %+v, want %+v", *snapshot.Status.Summary, tt.summary)
+			}
+			if got := conditionReason(snapshot, discoverysnapshot.ConditionDevicesMatched); got != discoverysnapshot.ReasonAmbiguousMatches {
+				t.Errorf("DevicesMatched reason = %s, want %s", got, discoverysnapshot.ReasonAmbiguousMatches)
+			}
+			for _, dev := range loadDevices(t) {
+				if dev.Status.DeletedAt != nil {
+					t.Errorf("%s marked removed; ambiguous candidates were seen", dev.GetUID())
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileResults(t *testing.T) {
+	r, _ := newTestReconciler(t)
+	ingest(t, r, payload{source: "10.0.0.1", entries: []entry{
+		{"Node", "Contoso", "SN1", "/Systems/1", ""},
+		{"CPU", "Contoso", "CPU1", "/Systems/1/Processors/CPU1", "/Systems/1"},
+	}})
+	snapshot := ingest(t, r, payload{source: "10.0.0.1", entries: []entry{
+		{"Node", "Contoso", "SN1", "/Systems/1", ""},
+		{"DIMM", "Contoso", "D1", "/Systems/1/Memory/DIMM1", "/Systems/1"},
+	}})
+
+	got := make(map[string]string) // Name -> action
+	for _, result := range snapshot.Status.Results {
+		if result.UID == "" || result.Error != "" {
+			t.Errorf("result %+v, want a UID and no error", result)
+		}
+		got[result.Name] = result.Action
+	}
+	want := map[string]string{
+		"Node-SN1": discoverysnapshot.ActionUnchanged,
+		"DIMM-D1":  discoverysnapshot.ActionCreated,
+		"CPU-CPU1": discoverysnapshot.ActionRemoved,
+	}
+	if len(got) != len(want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	for name, action := range want {
+		if got[name] != action {
+			t.Errorf("%s action = %q, want %q", name, got[name], action)
+		}
+	}
+
+	if snapshot.Status.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", snapshot.Status.Attempts)
+	}
+	if snapshot.Status.StartedAt == nil || snapshot.Status.CompletedAt == nil || snapshot.Status.CompletedAt.Before(*snapshot.Status.StartedAt) {
+		t.Errorf("startedAt = %v, completedAt = %v", snapshot.Status.StartedAt, snapshot.Status.CompletedAt)
+	}
+	wantMessage := "Snapshot processed successfully: 1 created, 0 updated, 1 unchanged, 1 removed, 0 failed."
+	if snapshot.Status.Message != wantMessage {
+		t.Errorf("message = %q, want %q", snapshot.Status.Message, wantMessage)
+	}
+}
+
+func TestReconcileSaveFailure(t *testing.T) {
+	r, backend := newTestReconciler(t)
+	r.MaxAttempts = 1
+	ingest(t, r, payload{source: "10.0.0.1", entries: []entry{
+		{"Node", "Contoso", "SN1", "/Systems/1", ""},
+		{"CPU", "Contoso", "CPU1", "/Systems/1/Processors/CPU1", "/Systems/1"},
+	}})
+	before := loadDevices(t)
+
+	// The CPU is removed and two DIMMs are added; the second write fails
+	backend.passSaves, backend.failSaves = 1, 1
+	uid := post(t, payload{source: "10.0.0.1", entries: []entry{
+		{"Node", "Contoso", "SN1", "/Systems/1", ""},
+		{"DIMM", "Contoso", "D1", "/Systems/1/Memory/DIMM1", "/Systems/1"},
+		{"DIMM", "Contoso", "D2", "/Systems/1/Memory/DIMM2", "/Systems/1"},
+	}}).GetUID()
+	snapshot, _ := reconcileOnce(t, r, uid)
+
+	if phase := snapshot.Status.CurrentPhase(); phase != discoverysnapshot.PhaseError {
+		t.Errorf("phase = %s, want Error", phase)
+	}
+	want := discoverysnapshot.IngestSummary{Unchanged: 1, Failed: 3}
+	if *snapshot.Status.Summary != want {
+		t.Errorf("summary = %+v, want %+v", *snapshot.Status.Summary, want)
+	}
+	after := loadDevices(t)
+	if len(after) != len(before) {
+		t.Fatalf("devices = %d, want the %d from before", len(after), len(before))
+	}
+	for _, dev := range after {
+		if dev.Status.DeletedAt != nil {
+			t.Errorf("%s marked removed by a failed snapshot", dev.GetName())
+		}
+	}
+}
+
+func TestReconcileRetries(t *testing.T) {
+	valid := payload{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "SN1", "/Systems/1", ""}}}
+	tests := []struct {
+		name      string
+		payload   payload
+		rawData   string   // Overrides the payload
+		failSaves int      // -1 fails every Device write
+		phases    []string // After each attempt
+		reason    string   // Processed condition reason at the end
+		devices   int
+	}{
+		{
+			name:    "success",
+			payload: valid,
+			phases:  []string{discoverysnapshot.PhaseComplete},
+			reason:  discoverysnapshot.ReasonApplied,
+			devices: 1,
+		},
+		{
+			name:      "storage error, then success",
+			payload:   valid,
+			failSaves: 1,
+			phases:    []string{discoverysnapshot.PhasePending, discoverysnapshot.PhaseComplete},
+			reason:    discoverysnapshot.ReasonApplied,
+			devices:   1,
+		},
+		{
+			name:      "storage errors until attempts run out",
+			payload:   valid,
+			failSaves: -1,
+			phases:    []string{discoverysnapshot.PhasePending, discoverysnapshot.PhasePending, discoverysnapshot.PhaseError},
+			reason:    discoverysnapshot.ReasonRetriesExhausted,
+		},
+		{
+			name:    "malformed payload",
+			rawData: `{"not": "a list"}`,
+			phases:  []string{discoverysnapshot.PhaseError},
+			reason:  discoverysnapshot.ReasonApplyFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, backend := newTestReconciler(t)
+			backend.failSaves = tt.failSaves
+			snapshot := post(t, tt.payload)
+			if tt.rawData != "" {
+				snapshot.Spec.RawData = json.RawMessage(tt.rawData)
+				if err := storage.SaveDiscoverySnapshot(context.Background(), snapshot); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for i, want := range tt.phases {
+				var result reconcile.Result
+				snapshot, result = reconcileOnce(t, r, snapshot.GetUID())
+				if got := snapshot.Status.CurrentPhase(); got != want {
+					t.Fatalf("attempt %d: phase = %s (%s), want %s", i+1, got, snapshot.Status.Message, want)
+				}
+				if snapshot.Status.Attempts != i+1 {
+					t.Errorf("attempt %d: status.attempts = %d", i+1, snapshot.Status.Attempts)
+				}
+				if retrying := want == discoverysnapshot.PhasePending; retrying != (result.RequeueAfter > 0) {
+					t.Errorf("attempt %d: requeueAfter = %s", i+1, result.RequeueAfter)
+				}
+			}
+			if got := processedReason(snapshot); got != tt.reason {
+				t.Errorf("Processed reason = %s, want %s", got, tt.reason)
+			}
+			if got := len(loadDevices(t)); got != tt.devices {
+				t.Errorf("devices = %d, want %d", got, tt.devices)
+			}
+
+			// Finished snapshots are left alone when their event is re-delivered
+			again, result := reconcileOnce(t, r, snapshot.GetUID())
+			if again.Status.Attempts != snapshot.Status.Attempts || result.RequeueAfter != 0 {
+				t.Errorf("re-delivered event reprocessed the snapshot")
+			}
+		})
+	}
+}
+
+func processedReason(snapshot *discoverysnapshot.DiscoverySnapshot) string {
+	return conditionReason(snapshot, discoverysnapshot.ConditionProcessed)
+}
+
+func conditionReason(snapshot *discoverysnapshot.DiscoverySnapshot, conditionType string) string {
+	for _, c := range snapshot.Status.Conditions {
+		if c.Type == conditionType {
+			return c.Reason
+		}
+	}
+	return ""
+}
+
+func TestReconcileResumesInterruptedAttempt(t *testing.T) {
+	r, _ := newTestReconciler(t)
+	snapshot := post(t, payload{source: "10.0.0.1", entries: []entry{{"Node", "Contoso", "SN1", "/Systems/1", ""}}})
+	snapshot.Status.Phase = discoverysnapshot.PhaseProcessing
+	snapshot.Status.Attempts = 1
+	if err := storage.SaveDiscoverySnapshot(context.Background(), snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	snapshot, _ = reconcileOnce(t, r, snapshot.GetUID())
+	if phase := snapshot.Status.CurrentPhase(); phase != discoverysnapshot.PhaseComplete || snapshot.Status.Attempts != 2 {
+		t.Errorf("phase = %s after %d attempts, want Complete after 2", phase, snapshot.Status.Attempts)
+	}
+	if got := len(loadDevices(t)); got != 1 {
+		t.Errorf("devices = %d, want 1", got)
+	}
+}
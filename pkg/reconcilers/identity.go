@@ -0,0 +1,197 @@
+// pkg/reconcilers/identity.go
+package reconcilers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// MatchKey names a rule for recognising a discovered device as one we already know.
+type MatchKey string
+
+const (
+	// MatchSerialManufacturer matches on deviceType + manufacturer + serialNumber.
+	// The device type is included because a System and its Chassis often share a serial.
+	MatchSerialManufacturer MatchKey = "serial_manufacturer"
+	// MatchRedfishURI matches on the redfish_uri property, scoped to the snapshot source (the BMC).
+	MatchRedfishURI MatchKey = "redfish_uri"
+	// MatchHPCMUUID matches on the HPCM node uuid (the old_uuid property).
+	MatchHPCMUUID MatchKey = "hpcm_uuid"
+	// MatchDiscoveryRef matches on the discovery_ref property that non-Redfish payload
+	// decoders set, scoped to the snapshot source like redfish_uri.
+	MatchDiscoveryRef MatchKey = "discovery_ref"
+)
+
+// DefaultMatchKeys is the order used when the reconciler is not configured otherwise.
+var DefaultMatchKeys = []MatchKey{MatchSerialManufacturer, MatchRedfishURI, MatchHPCMUUID, MatchDiscoveryRef}
+
+// Property keys used for identity matching.
+const (
+	// propDiscoverySource records the snapshot source (BMC address) a device was last seen through.
+	propDiscoverySource = "discovery_source"
+	// propHPCMUUID is where HPCM imports keep the node uuid (see test-data/populate_node.sh).
+	propHPCMUUID = "old_uuid"
+)
+
+// placeholderSerials are values BMCs report when a part has no real serial number.
+// Matching on them would merge unrelated devices.
+var placeholderSerials = map[string]bool{
+	"":                        true,
+	"0":                       true,
+	"N/A":                     true,
+	"NA":                      true,
+	"NONE":                    true,
+	"NULL":                    true,
+	"UNKNOWN":                 true,
+	"NOT SPECIFIED":           true,
+	"NOT AVAILABLE":           true,
+	"TO BE FILLED BY O.E.M.":  true,
+	"DEFAULT STRING":          true,
+	"SYSTEM SERIAL NUMBER":    true,
+	"0123456789":              true,
+	"NO SERIAL":               true,
+	"SERIAL NUMBER UNDEFINED": true,
+}
+
+// ParseMatchKeys validates a configured list of match key names.
+func ParseMatchKeys(names []string) ([]MatchKey, error) {
+	keys := make([]MatchKey, 0, len(names))
+	for _, name := range names {
+		key := MatchKey(strings.TrimSpace(name))
+		switch key {
+		case MatchSerialManufacturer, MatchRedfishURI, MatchHPCMUUID, MatchDiscoveryRef:
+			keys = append(keys, key)
+		default:
+			return nil, fmt.Errorf("unknown identity match key %q (valid: %s, %s, %s, %s)",
+				name, MatchSerialManufacturer, MatchRedfishURI, MatchHPCMUUID, MatchDiscoveryRef)
+		}
+	}
+	return keys, nil
+}
+
+// identityValue returns the lookup value of key for a device, or "" if the
+// device does not carry the fields that key needs.
+func identityValue(key MatchKey, status *device.DeviceStatus, source string) string {
+	switch key {
+	case MatchSerialManufacturer:
+		serial := strings.ToUpper(strings.TrimSpace(status.SerialNumber))
+		if placeholderSerials[serial] || status.Manufacturer == "" {
+			return ""
+		}
+		return strings.Join([]string{status.DeviceType, strings.ToUpper(strings.TrimSpace(status.Manufacturer)), serial}, "|")
+	case MatchRedfishURI:
+		return scopedURI(source, snapshotformat.StringProperty(status, propRedfishURI))
+	case MatchHPCMUUID:
+		return strings.ToLower(snapshotformat.StringProperty(status, propHPCMUUID))
+	case MatchDiscoveryRef:
+		return scopedURI(source, snapshotformat.StringProperty(status, snapshotformat.PropDiscoveryRef))
+	}
+	return ""
+}
+
+// scopedURI qualifies a Redfish URI (or discovery_ref) with the source it came
+// from; URIs like /Systems/1 are only unique per BMC. Without a source there
+// is nothing to scope by, so it returns "" and the URI identifies nothing.
+func scopedURI(source, uri string) string {
+	if source == "" || uri == "" {
+		return ""
+	}
+	return source + "|" + uri
+}
+
+// snapshotSource returns the source a snapshot's devices are scoped to: the
+// given source, else the provenance source. A non-Redfish payload with neither
+// describes a single node, so it is scoped to that node's discovery_ref, which
+// its decoder builds from the HPCM node uuid or the lshw hostname. Anything
+// else has no source.
+func snapshotSource(source string, env *snapshotformat.Envelope, entries []snapshotformat.Entry) string {
+	if source != "" {
+		return source
+	}
+	if env.Provenance != nil && env.Provenance.Source != "" {
+		return env.Provenance.Source
+	}
+	var node string
+	for _, entry := range entries {
+		if entry.Status == nil || entry.ParentRef != "" || entry.Status.DeviceType != "Node" {
+			continue
+		}
+		ref := snapshotformat.StringProperty(entry.Status, snapshotformat.PropDiscoveryRef)
+		if ref == "" || node != "" {
+			return ""
+		}
+		node = ref
+	}
+	return node
+}
+
+// deviceIndex looks up existing Devices by each configured match key.
+type deviceIndex struct {
+	keys    []MatchKey
+	entries map[MatchKey]map[string][]*device.Device
+}
+
+func newDeviceIndex(keys []MatchKey, devices []*device.Device) *deviceIndex {
+	idx := &deviceIndex{
+		keys:    keys,
+		entries: make(map[MatchKey]map[string][]*device.Device, len(keys)),
+	}
+	for _, key := range keys {
+		idx.entries[key] = make(map[string][]*device.Device)
+	}
+	for _, dev := range devices {
+		idx.add(dev)
+	}
+	return idx
+}
+
+// add indexes a device under every key it has a value for.
+func (idx *deviceIndex) add(dev *device.Device) {
+	source := snapshotformat.StringProperty(&dev.Status, propDiscoverySource)
+	for _, key := range idx.keys {
+		value := identityValue(key, &dev.Status, source)
+		if value == "" {
+			continue
+		}
+		candidates := idx.entries[key][value]
+		alreadyIndexed := false
+		for _, c := range candidates {
+			if c.GetUID() == dev.GetUID() {
+				alreadyIndexed = true
+				break
+			}
+		}
+		if !alreadyIndexed {
+			idx.entries[key][value] = append(candidates, dev)
+		}
+	}
+}
+
+// match tries each key in order. The first key that finds exactly one device wins;
+// a key that finds several stops the search and returns the candidate UIDs, so we
+// never guess between two existing records.
+func (idx *deviceIndex) match(status *device.DeviceStatus, source string) (*device.Device, MatchKey, []string) {
+	for _, key := range idx.keys {
+		value := identityValue(key, status, source)
+		if value == "" {
+			continue
+		}
+		candidates := idx.entries[key][value]
+		switch len(candidates) {
+		case 0:
+			continue
+		case 1:
+			return candidates[0], key, nil
+		default:
+			uids := make([]string, 0, len(candidates))
+			for _, c := range candidates {
+				uids = append(uids, c.GetUID())
+			}
+			return nil, key, uids
+		}
+	}
+	return nil, "", nil
+}
@@ -0,0 +1,50 @@
+package reconcilers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMatchKeys(t *testing.T) {
+	tests := []struct {
+		names   []string
+		want    []MatchKey
+		wantErr bool
+	}{
+		{names: []string{"redfish_uri", " serial_manufacturer "}, want: []MatchKey{MatchRedfishURI, MatchSerialManufacturer}},
+		{names: nil, want: []MatchKey{}},
+		{names: []string{"serial"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMatchKeys(tt.names)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMatchKeys(%q) error = %v, wantErr %v", tt.names, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseMatchKeys(%q) = %v, want %v", tt.names, got, tt.want)
+		}
+	}
+}
+
+func TestIdentityValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    MatchKey
+		entry  entry
+		source string
+		want   string
+	}{
+		{"serial", MatchSerialManufacturer, entry{"CPU", "Contoso ", " cpu1", "", ""}, "", "CPU|CONTOSO|CPU1"},
+		{"placeholder serial", MatchSerialManufacturer, entry{"CPU", "Contoso", "To Be Filled By O.E.M.", "", ""}, "", ""},
+		{"no manufacturer", MatchSerialManufacturer, entry{"CPU", "", "CPU1", "", ""}, "", ""},
+		{"redfish uri", MatchRedfishURI, entry{"CPU", "", "", "/Systems/1/Processors/CPU1", ""}, "10.0.0.1", "10.0.0.1|/Systems/1/Processors/CPU1"},
+		{"no redfish uri", MatchRedfishURI, entry{"CPU", "", "", "", ""}, "10.0.0.1", ""},
+		{"redfish uri without a source", MatchRedfishURI, entry{"CPU", "", "", "/Systems/1/Processors/CPU1", ""}, "", ""},
+	}
+	for _, tt := range tests {
+		if got := identityValue(tt.key, tt.entry.status(), tt.source); got != tt.want {
+			t.Errorf("%s: identityValue = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+// pkg/reconcilers/retry.go
+package reconcilers
+
+import (
+	"errors"
+	"time"
+)
+
+// Retry defaults for snapshots that fail with a transient error.
+const (
+	DefaultMaxAttempts    = 5
+	DefaultRetryBaseDelay = 2 * time.Second
+	// maxRetryDelay caps the exponential backoff.
+	maxRetryDelay = 5 * time.Minute
+)
+
+// transientError marks a failure that may succeed if retried, such as a storage
+// read or write error. Anything else (a malformed payload, say) is permanent.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string { return e.err.Error() }
+func (e *transientError) Unwrap() error { return e.err }
+
+// transient wraps err as retryable.
+func transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &transientError{err: err}
+}
+
+// isTransient reports whether err, or anything it wraps, is retryable.
+func isTransient(err error) bool {
+	var t *transientError
+	return errors.As(err, &t)
+}
+
+// retryDelay returns the backoff before the next try after the given attempt:
+// base, 2*base, 4*base, ... capped at maxRetryDelay.
+func retryDelay(base time.Duration, attempt int) time.Duration {
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= maxRetryDelay {
+			return maxRetryDelay
+		}
+	}
+	return delay
+}
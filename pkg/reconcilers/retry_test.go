@@ -0,0 +1,40 @@
+package reconcilers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		base    time.Duration
+		attempt int
+		want    time.Duration
+	}{
+		{2 * time.Second, 1, 2 * time.Second},
+		{2 * time.Second, 2, 4 * time.Second},
+		{2 * time.Second, 4, 16 * time.Second},
+		{2 * time.Second, 20, maxRetryDelay},
+		{time.Minute, 4, maxRetryDelay},
+	}
+	for _, tt := range tests {
+		if got := retryDelay(tt.base, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%s, %d) = %s, want %s", tt.base, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	cause := errors.New("disk full")
+	if !isTransient(fmt.Errorf("no devices were changed: %w", transient(cause))) {
+		t.Error("wrapped transient error not recognised")
+	}
+	if isTransient(cause) {
+		t.Error("plain error treated as transient")
+	}
+	if transient(nil) != nil {
+		t.Error("transient(nil) != nil")
+	}
+}
@@ -0,0 +1,170 @@
+// pkg/reconcilers/snapshot_diff.go
+package reconcilers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// DiffSnapshots compares the devices in two snapshots. Entries are paired with
+// the same identity rules the reconciler uses to match Devices, so a DIMM whose
+// firmware changed shows up as changed rather than as removed and re-added.
+// Empty keys means DefaultMatchKeys.
+func DiffSnapshots(from, to *discoverysnapshot.DiscoverySnapshot, keys []MatchKey) (*discoverysnapshot.SnapshotDiff, error) {
+	if len(keys) == 0 {
+		keys = DefaultMatchKeys
+	}
+	fromEntries, err := diffEntries(from)
+	if err != nil {
+		return nil, err
+	}
+	toEntries, err := diffEntries(to)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the older snapshot's entries as if they were stored Devices; the
+	// UID is just the entry's position.
+	olds := make([]*device.Device, len(fromEntries))
+	for i, status := range fromEntries {
+		olds[i] = &device.Device{Status: *status}
+		olds[i].Metadata.UID = strconv.Itoa(i)
+	}
+	index := newDeviceIndex(keys, olds)
+
+	diff := &discoverysnapshot.SnapshotDiff{
+		From:    from.GetUID(),
+		To:      to.GetUID(),
+		Added:   []discoverysnapshot.DeviceDiff{},
+		Removed: []discoverysnapshot.DeviceDiff{},
+		Changed: []discoverysnapshot.DeviceDiff{},
+	}
+	claimed := make(map[string]bool, len(olds))
+	for _, status := range toEntries {
+		old, key, _ := index.match(status, snapshotformat.StringProperty(status, propDiscoverySource))
+		if old == nil || claimed[old.GetUID()] {
+			// Unmatched, or ambiguous: report it as new rather than guess.
+			diff.Added = append(diff.Added, deviceDiff(keys, status, true))
+			continue
+		}
+		claimed[old.GetUID()] = true
+
+		changes := diffStatus(&old.Status, status)
+		if len(changes) == 0 {
+			diff.Unchanged++
+			continue
+		}
+		dd := deviceDiff([]MatchKey{key}, status, false)
+		dd.Changes = changes
+		diff.Changed = append(diff.Changed, dd)
+	}
+	for _, old := range olds {
+		if !claimed[old.GetUID()] {
+			diff.Removed = append(diff.Removed, deviceDiff(keys, &old.Status, true))
+		}
+	}
+
+	for _, list := range [][]discoverysnapshot.DeviceDiff{diff.Added, diff.Removed, diff.Changed} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	}
+	return diff, nil
+}
+
+// diffEntries decodes a snapshot's payload and tags every entry with the
+// snapshot source, as the reconciler does, so scoped identity rules line up.
+func diffEntries(snapshot *discoverysnapshot.DiscoverySnapshot) ([]*device.DeviceStatus, error) {
+	if snapshot.Status.RawDataStrippedAt != nil {
+		return nil, fmt.Errorf("snapshot %s: raw data was removed by the retention policy", snapshot.GetUID())
+	}
+	env, entries, err := snapshotformat.Decode(snapshot.Spec.RawData)
+	if err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", snapshot.GetUID(), err)
+	}
+	source := snapshotSource(snapshot.SourceAddress(), env, entries)
+	statuses := make([]*device.DeviceStatus, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Status == nil {
+			continue
+		}
+		snapshotformat.SetStringProperty(entry.Status, propDiscoverySource, source)
+		statuses = append(statuses, entry.Status)
+	}
+	return statuses, nil
+}
+
+// deviceDiff describes a device, naming it by the first of keys it has a value for.
+func deviceDiff(keys []MatchKey, status *device.DeviceStatus, withDevice bool) discoverysnapshot.DeviceDiff {
+	dd := discoverysnapshot.DeviceDiff{
+		Name:       deviceName(status),
+		DeviceType: status.DeviceType,
+	}
+	source := snapshotformat.StringProperty(status, propDiscoverySource)
+	for _, key := range keys {
+		if value := identityValue(key, status, source); value != "" {
+			dd.Identity = fmt.Sprintf("%s=%s", key, value)
+			break
+		}
+	}
+	if withDevice {
+		dd.Device = status
+	}
+	return dd
+}
+
+// diffStatus lists the fields that differ between two entries.
+func diffStatus(from, to *device.DeviceStatus) []discoverysnapshot.FieldChange {
+	var changes []discoverysnapshot.FieldChange
+	compare := func(field string, a, b json.RawMessage) {
+		if bytes.Equal(compactJSON(a), compactJSON(b)) {
+			return
+		}
+		changes = append(changes, discoverysnapshot.FieldChange{Field: field, From: a, To: b})
+	}
+	compare("deviceType", jsonString(from.DeviceType), jsonString(to.DeviceType))
+	compare("manufacturer", jsonString(from.Manufacturer), jsonString(to.Manufacturer))
+	compare("partNumber", jsonString(from.PartNumber), jsonString(to.PartNumber))
+	compare("serialNumber", jsonString(from.SerialNumber), jsonString(to.SerialNumber))
+
+	keys := make(map[string]bool, len(from.Properties)+len(to.Properties))
+	for key := range from.Properties {
+		keys[key] = true
+	}
+	for key := range to.Properties {
+		keys[key] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+	for _, key := range sorted {
+		compare("properties."+key, from.Properties[key], to.Properties[key])
+	}
+	return changes
+}
+
+// jsonString encodes s, or returns nil for "" so unset fields are omitted.
+func jsonString(s string) json.RawMessage {
+	if s == "" {
+		return nil
+	}
+	raw, _ := json.Marshal(s)
+	return raw
+}
+
+// compactJSON strips insignificant whitespace so formatting differences
+// between payloads do not count as changes.
+func compactJSON(raw json.RawMessage) []byte {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return raw
+	}
+	return buf.Bytes()
+}
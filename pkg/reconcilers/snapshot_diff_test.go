@@ -0,0 +1,125 @@
+package reconcilers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+	"github.com/user/inventory-api/pkg/snapshotformat"
+)
+
+// snapshotOf builds an unsaved snapshot holding p.
+func snapshotOf(t *testing.T, uid string, p payload) *discoverysnapshot.DiscoverySnapshot {
+	t.Helper()
+	snapshot := &discoverysnapshot.DiscoverySnapshot{
+		Spec: discoverysnapshot.DiscoverySnapshotSpec{Source: p.source, RawData: p.rawData(t)},
+	}
+	snapshot.Metadata.Initialize("snapshot-"+uid, uid)
+	return snapshot
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	node := entry{"Node", "Contoso", "SN1", "/Systems/1", ""}
+	cpu1 := entry{"CPU", "Contoso", "CPU1", "/Systems/1/Processors/CPU1", "/Systems/1"}
+	cpu2 := entry{"CPU", "Contoso", "CPU2", "/Systems/1/Processors/CPU2", "/Systems/1"}
+	dimm := entry{"DIMM", "Contoso", "D1", "/Systems/1/Memory/DIMM1", "/Systems/1"}
+
+	from := snapshotOf(t, "ds-from", payload{source: "10.0.0.1", entries: []entry{node, cpu1, cpu2}})
+	to := snapshotOf(t, "ds-to", payload{source: "10.0.0.1", entries: []entry{
+		node,
+		{"CPU", "Contoso", "CPU9", "/Systems/1/Processors/CPU1", "/Systems/1"}, // Replaced at the same URI
+		dimm,
+	}})
+
+	diff, err := DiffSnapshots(from, to, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.From != "ds-from" || diff.To != "ds-to" {
+		t.Errorf("diff from %s to %s", diff.From, diff.To)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("unchanged = %d, want 1", diff.Unchanged)
+	}
+	if names := diffNames(diff.Added); names != "DIMM-D1" {
+		t.Errorf("added = %s, want DIMM-D1", names)
+	}
+	if names := diffNames(diff.Removed); names != "CPU-CPU2" {
+		t.Errorf("removed = %s, want CPU-CPU2", names)
+	}
+	if len(diff.Changed) != 1 {
+		t.Fatalf("changed = %+v, want the CPU at CPU1", diff.Changed)
+	}
+	changed := diff.Changed[0]
+	if changed.Name != "CPU-CPU9" || changed.Identity != "redfish_uri=10.0.0.1|/Systems/1/Processors/CPU1" {
+		t.Errorf("changed = %s by %s", changed.Name, changed.Identity)
+	}
+	if len(changed.Changes) != 1 || changed.Changes[0].Field != "serialNumber" ||
+		string(changed.Changes[0].From) != `"CPU1"` || string(changed.Changes[0].To) != `"CPU9"` {
+		t.Errorf("changes = %s", changeList(changed.Changes))
+	}
+	if diff.Added[0].Device == nil || diff.Removed[0].Device == nil || changed.Device != nil {
+		t.Errorf("only added and removed devices carry the full entry")
+	}
+}
+
+func TestDiffSnapshotsProperties(t *testing.T) {
+	withFirmware := func(version string) payload {
+		e := entry{"DIMM", "Contoso", "D1", "/Systems/1/Memory/DIMM1", ""}.status()
+		if version != "" {
+			snapshotformat.SetStringProperty(e, "firmware_version", version)
+		}
+		snapshotformat.SetStringProperty(e, "location", "DIMM 1")
+		data, _ := json.Marshal([]interface{}{e})
+		return payload{source: "10.0.0.1", raw: data}
+	}
+	tests := []struct {
+		name     string
+		from, to payload
+		changes  string
+	}{
+		{"property changed", withFirmware("1.0"), withFirmware("1.1"), `properties.firmware_version: "1.0" -> "1.1"`},
+		{"property added", withFirmware(""), withFirmware("1.1"), `properties.firmware_version:  -> "1.1"`},
+		{"property removed", withFirmware("1.0"), withFirmware(""), `properties.firmware_version: "1.0" -> `},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff, err := DiffSnapshots(snapshotOf(t, "a", tt.from), snapshotOf(t, "b", tt.to), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(diff.Added)+len(diff.Removed) != 0 || len(diff.Changed) != 1 {
+				t.Fatalf("diff = %+v, want one changed device", diff)
+			}
+			if got := changeList(diff.Changed[0].Changes); got != tt.changes {
+				t.Errorf("changes = %s, want %s", got, tt.changes)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsBadPayload(t *testing.T) {
+	good := snapshotOf(t, "a", payload{source: "10.0.0.1"})
+	bad := snapshotOf(t, "b", payload{source: "10.0.0.1"})
+	bad.Spec.RawData = json.RawMessage(`{"format": "nope/v1", "data": []}`)
+	if _, err := DiffSnapshots(good, bad, nil); err == nil || !strings.Contains(err.Error(), "snapshot b") {
+		t.Errorf("DiffSnapshots error = %v, want one naming snapshot b", err)
+	}
+}
+
+func diffNames(list []discoverysnapshot.DeviceDiff) string {
+	names := make([]string, 0, len(list))
+	for _, dd := range list {
+		names = append(names, dd.Name)
+	}
+	return strings.Join(names, " ")
+}
+
+func changeList(changes []discoverysnapshot.FieldChange) string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		lines = append(lines, c.Field+": "+string(c.From)+" -> "+string(c.To))
+	}
+	return strings.Join(lines, "; ")
+}
@@ -0,0 +1,334 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package redfishmock serves a Redfish mockup over httptest, so the collector
+// and the whole ingest pipeline can run without hardware.
+//
+// A mockup uses the DMTF Redfish-Mockup-Server layout: one index.json per
+// URI, e.g. redfish/v1/Systems/1/index.json for /redfish/v1/Systems/1. The
+// leading redfish/v1 directory may be left out. A URI without an index.json
+// is a 404, which is how a mockup describes a collection with missing members.
+//
+// A mockup may also carry a faults.json beside its redfish directory making
+// URIs fail with an HTTP status, always or for their first few requests:
+//
+//	{"faults": [{"path": "/redfish/v1/Systems/1/Memory/DIMM2", "status": 500},
+//	            {"path": "/redfish/v1/Managers/BMC", "status": 503, "count": 2}]}
+//
+// The server accepts basic auth and SessionService logins, with any
+// credentials unless Username is set.
+package redfishmock
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed mockups
+var bundled embed.FS
+
+const (
+	serviceRoot  = "/redfish/v1"
+	sessionsPath = serviceRoot + "/SessionService/Sessions"
+)
+
+// Mockups lists the bundled mockups:
+//
+//	single-node          one node in a rack-mount chassis, with CPUs, DIMMs, storage, a NIC, PSUs, fans and firmware inventory
+//	gpu-node             single-node with four GPUs and an FPGA on chassis PCIe devices
+//	multi-node-chassis   a rack holding an enclosure of four blades, one node each
+//	missing-members      single-node with collection members and a thermal subsystem that 404
+//	server-errors        single-node where some resources return 500 or 503
+func Mockups() []string {
+	entries, _ := fs.ReadDir(bundled, "mockups")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Mockup returns a bundled mockup by name.
+func Mockup(name string) (fs.FS, error) {
+	dir := path.Join("mockups", name)
+	if name == "" || strings.Contains(name, "/") || !fs.ValidPath(dir) || !exists(bundled, dir) {
+		return nil, fmt.Errorf("unknown mockup %q (have %s)", name, strings.Join(Mockups(), ", "))
+	}
+	return fs.Sub(bundled, dir)
+}
+
+// Fault makes a URI fail with Status. Count > 0 fails only that many requests.
+type Fault struct {
+	Path   string `json:"path"`
+	Status int    `json:"status"`
+	Count  int    `json:"count,omitempty"`
+}
+
+// Handler serves a mockup. It can be used without a listener, e.g. as the
+// transport of an http.Client.
+type Handler struct {
+	// Username and Password, if set, are the only credentials accepted.
+	Username, Password string
+
+	mockup fs.FS
+	prefix string // Directory in mockup that holds /redfish/v1
+
+	mu       sync.Mutex
+	faults   map[string]*Fault
+	sessions map[string]bool
+	nextID   int
+	requests map[string]int
+}
+
+// Server is a running mockup. The embedded httptest.Server gives its URL,
+// certificate and Close; the embedded Handler its faults and counters.
+type Server struct {
+	*httptest.Server
+	*Handler
+}
+
+// NewServer starts an HTTPS server for a mockup.
+func NewServer(mockup fs.FS) (*Server, error) {
+	s, err := NewUnstartedServer(mockup)
+	if err != nil {
+		return nil, err
+	}
+	s.StartTLS()
+	return s, nil
+}
+
+// NewUnstartedServer prepares a server for a mockup without starting it, so
+// the caller can pick plain HTTP (Start) or HTTPS (StartTLS) or set a listener.
+func NewUnstartedServer(mockup fs.FS) (*Server, error) {
+	h, err := NewHandler(mockup)
+	if err != nil {
+		return nil, err
+	}
+	return &Server{Server: httptest.NewUnstartedServer(h), Handler: h}, nil
+}
+
+// NewHandler reads a mockup's layout and faults.json.
+func NewHandler(mockup fs.FS) (*Handler, error) {
+	h := &Handler{
+		mockup:   mockup,
+		faults:   make(map[string]*Fault),
+		sessions: make(map[string]bool),
+		requests: make(map[string]int),
+	}
+	switch {
+	case exists(mockup, "redfish/v1/index.json"):
+		h.prefix = "redfish/v1"
+	case exists(mockup, "index.json"):
+		h.prefix = "."
+	default:
+		return nil, errors.New("mockup has no service root (redfish/v1/index.json)")
+	}
+
+	if data, err := fs.ReadFile(mockup, "faults.json"); err == nil {
+		var file struct {
+			Faults []Fault `json:"faults"`
+		}
+		if err := json.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("invalid faults.json: %w", err)
+		}
+		for _, f := range file.Faults {
+			h.Fail(f.Path, f.Status, f.Count)
+		}
+	}
+	return h, nil
+}
+
+// Address is the server's host:port, as a collector target.
+func (s *Server) Address() string {
+	return s.Listener.Addr().String()
+}
+
+// Fail makes requests for uri return status; count > 0 limits it to that
+// many requests. A status of 0 removes the fault.
+func (h *Handler) Fail(uri string, status, count int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	uri = cleanPath(uri)
+	if status == 0 {
+		delete(h.faults, uri)
+		return
+	}
+	h.faults[uri] = &Fault{Path: uri, Status: status, Count: count}
+}
+
+// Requests returns how many requests were made for uri (any method).
+func (h *Handler) Requests(uri string) int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.requests[cleanPath(uri)]
+}
+
+// Sessions returns how many sessions are open.
+func (h *Handler) Sessions() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.sessions)
+}
+
+// ServeHTTP answers a Redfish request from the mockup.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	uri := cleanPath(r.URL.Path)
+	if status, ok := h.record(uri); ok {
+		writeError(w, status)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && uri == sessionsPath:
+		h.login(w, r)
+		return
+	case r.Method == http.MethodDelete && strings.HasPrefix(uri, sessionsPath+"/"):
+		h.logout(w, r)
+		return
+	case r.Method != http.MethodGet && r.Method != http.MethodHead:
+		writeError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	// The service root is readable without credentials, as the spec requires.
+	if uri != "/redfish" && uri != serviceRoot && !h.authorized(r) {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+	if uri == "/redfish" {
+		writeJSON(w, http.StatusOK, map[string]string{"v1": serviceRoot + "/"})
+		return
+	}
+
+	if !strings.HasPrefix(uri, serviceRoot) {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	rel := strings.TrimPrefix(strings.TrimPrefix(uri, serviceRoot), "/")
+	data, err := fs.ReadFile(h.mockup, path.Join(h.prefix, rel, "index.json"))
+	if err != nil {
+		writeError(w, http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("OData-Version", "4.0")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// record counts a request and returns the injected failure for uri, if any.
+func (h *Handler) record(uri string) (int, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.requests[uri]++
+	f, ok := h.faults[uri]
+	if !ok {
+		return 0, false
+	}
+	if f.Count > 0 {
+		f.Count--
+		if f.Count == 0 {
+			delete(h.faults, uri)
+		}
+	}
+	return f.Status, true
+}
+
+// authorized checks basic auth or an X-Auth-Token.
+func (h *Handler) authorized(r *http.Request) bool {
+	if token := r.Header.Get("X-Auth-Token"); token != "" {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		return h.sessions[token]
+	}
+	user, pass, ok := r.BasicAuth()
+	return ok && h.credentialsOK(user, pass)
+}
+
+func (h *Handler) credentialsOK(user, pass string) bool {
+	if h.Username == "" {
+		return user != ""
+	}
+	return user == h.Username && pass == h.Password
+}
+
+func (h *Handler) login(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		UserName string
+		Password string
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest)
+		return
+	}
+	if !h.credentialsOK(body.UserName, body.Password) {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+
+	h.mu.Lock()
+	h.nextID++
+	id := h.nextID
+	token := fmt.Sprintf("mock-token-%d", id)
+	h.sessions[token] = true
+	h.mu.Unlock()
+
+	location := fmt.Sprintf("%s/%d", sessionsPath, id)
+	w.Header().Set("X-Auth-Token", token)
+	w.Header().Set("Location", location)
+	writeJSON(w, http.StatusCreated, map[string]string{"@odata.id": location, "Id": fmt.Sprint(id), "UserName": body.UserName})
+}
+
+func (h *Handler) logout(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	token := r.Header.Get("X-Auth-Token")
+	if !h.sessions[token] {
+		writeError(w, http.StatusUnauthorized)
+		return
+	}
+	delete(h.sessions, token)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeError sends a Redfish-style error body.
+func writeError(w http.ResponseWriter, status int) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{
+			"code":    "Base.1.0.GeneralError",
+			"message": http.StatusText(status),
+		},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// cleanPath drops a trailing slash so /redfish/v1/ and /redfish/v1 match.
+func cleanPath(p string) string {
+	if p != "/" {
+		p = strings.TrimSuffix(p, "/")
+	}
+	return p
+}
+
+func exists(fsys fs.FS, name string) bool {
+	_, err := fs.Stat(fsys, name)
+	return err == nil
+}
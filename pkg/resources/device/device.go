@@ -28,7 +28,9 @@ type DeviceSpec struct {
 }
 
 type DeviceStatus struct {
-	DeviceType   string `json:"deviceType,omitempty" validate:"omitempty,oneof=Node GPU Rack"`
+	// DeviceType is one of the types the collector and the snapshot decoders produce.
+	// FPGA and Accelerator are processors classified by their Redfish ProcessorType.
+	DeviceType   string `json:"deviceType,omitempty" validate:"omitempty,oneof=Node CPU GPU FPGA Accelerator DIMM StorageController Disk NIC PCIeDevice Rack Chassis Blade PowerSupply Fan BMC"`
 	Manufacturer string `json:"manufacturer,omitempty"`
 	PartNumber   string `json:"partNumber,omitempty"`
 	SerialNumber string `json:"serialNumber,omitempty"`
@@ -0,0 +1,47 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package discoverysnapshot
+
+import (
+	"encoding/json"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// SnapshotDiff is the device-level difference between two snapshots, as
+// returned by GET /discoverysnapshots/{a}/diff/{b}.
+type SnapshotDiff struct {
+	From string `json:"from"` // UID of the older snapshot
+	To   string `json:"to"`   // UID of the newer snapshot
+
+	Added     []DeviceDiff `json:"added"`     // In To but not in From
+	Removed   []DeviceDiff `json:"removed"`   // In From but not in To
+	Changed   []DeviceDiff `json:"changed"`   // In both, with different fields
+	Unchanged int          `json:"unchanged"` // In both and identical
+}
+
+// DeviceDiff describes one device in a SnapshotDiff.
+type DeviceDiff struct {
+	// Identity is the rule and value that paired the device across the two
+	// snapshots (e.g. "serial_manufacturer=CPU|INTEL|1234"), or for added and
+	// removed devices the first rule the device has a value for.
+	Identity   string `json:"identity"`
+	Name       string `json:"name"`
+	DeviceType string `json:"deviceType"`
+
+	// Device is the full entry, for added and removed devices.
+	Device *device.DeviceStatus `json:"device,omitempty"`
+	// Changes lists the differing fields, for changed devices.
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is one field that differs between the two snapshots. Properties
+// are named "properties.<key>". From or To is absent when the field is unset
+// on that side.
+type FieldChange struct {
+	Field string          `json:"field"`
+	From  json.RawMessage `json:"from,omitempty"`
+	To    json.RawMessage `json:"to,omitempty"`
+}
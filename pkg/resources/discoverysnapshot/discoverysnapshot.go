@@ -7,8 +7,12 @@ package discoverysnapshot
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"time"
 
 	"github.com/openchami/fabrica/pkg/resource"
+
+	"github.com/user/inventory-api/pkg/snapshotformat"
 )
 
 // DiscoverySnapshot is the resource that holds a raw hardware snapshot.
@@ -20,21 +24,125 @@ type DiscoverySnapshot struct {
 
 // DiscoverySnapshotSpec defines the desired state of DiscoverySnapshot
 type DiscoverySnapshotSpec struct {
+	// Source identifies where the payload was collected from (e.g., the BMC address).
+	// Redfish URIs are only unique per BMC, so the reconciler scopes device matching by it.
+	Source string `json:"source,omitempty"`
+
 	// RawData holds the complete, raw JSON payload from a discovery tool (e.g., the collector).
-	// The reconciler will parse this.
+	// It is an envelope, {"format": "redfish-collector/v1", "data": ...}, decoded by the
+	// pkg/snapshotformat decoder registered for its format. A bare array is read as
+	// redfish-collector/v1.
 	RawData json.RawMessage `json:"rawData" validate:"required"`
 }
 
 // DiscoverySnapshotStatus defines the observed state of DiscoverySnapshot
 type DiscoverySnapshotStatus struct {
-	Phase   string   `json:"phase,omitempty"`   // e.g., Pending, Processing, Complete, Error
+	Phase   string   `json:"phase,omitempty"`   // One of the Phase* constants (see lifecycle.go)
 	Message string   `json:"message,omitempty"` // A human-readable message
 	Logs    []string `json:"logs,omitempty"`    // Logs generated during reconciliation
+
+	// Conditions follow the Kubernetes pattern; see the Condition* and Reason* constants.
+	Conditions []resource.Condition `json:"conditions,omitempty"`
+
+	// Provenance is copied from the payload envelope: who collected it, from
+	// where, when, and which endpoints could not be read.
+	Provenance *snapshotformat.Provenance `json:"provenance,omitempty"`
+
+	// AmbiguousMatches lists discovered devices that matched more than one existing Device.
+	// These entries are skipped rather than guessed at.
+	AmbiguousMatches []AmbiguousMatch `json:"ambiguousMatches,omitempty"`
+
+	// Summary counts the outcome of the last processing attempt, one count per action.
+	Summary *IngestSummary `json:"summary,omitempty"`
+	// Results has one entry per device the last attempt created, updated, removed or failed on.
+	Results []DeviceResult `json:"results,omitempty"`
+
+	StartedAt   *time.Time `json:"startedAt,omitempty"`   // When the last processing attempt began
+	CompletedAt *time.Time `json:"completedAt,omitempty"` // When the last processing attempt finished
+	Attempts    int        `json:"attempts,omitempty"`    // How many times processing has been attempted since creation or the last reprocess
+
+	// RawDataStrippedAt is set when the retention policy removed spec.rawData
+	// to save space. Such a snapshot keeps its results but cannot be reprocessed or diffed.
+	RawDataStrippedAt *time.Time `json:"rawDataStrippedAt,omitempty"`
+}
+
+// Actions recorded in DeviceResult.Action.
+const (
+	ActionCreated   = "Created"
+	ActionUpdated   = "Updated"
+	ActionUnchanged = "Unchanged"
+	ActionRemoved   = "Removed"
+	ActionFailed    = "Failed"
+)
+
+// IngestSummary counts devices by the action taken on them.
+type IngestSummary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Removed   int `json:"removed"`
+	Failed    int `json:"failed"`
+}
+
+// Add counts one device under action.
+func (s *IngestSummary) Add(action string) {
+	switch action {
+	case ActionCreated:
+		s.Created++
+	case ActionUpdated:
+		s.Updated++
+	case ActionUnchanged:
+		s.Unchanged++
+	case ActionRemoved:
+		s.Removed++
+	case ActionFailed:
+		s.Failed++
+	}
+}
+
+// DeviceResult records what reconciliation did to a single device.
+type DeviceResult struct {
+	UID    string `json:"uid,omitempty"`   // Empty when no Device could be resolved (e.g. ambiguous matches)
+	Name   string `json:"name"`            // Device name, for readability
+	Action string `json:"action"`          // One of the Action* constants
+	Error  string `json:"error,omitempty"` // Why the action failed, if it did
+}
+
+// AmbiguousMatch records a discovered device whose identity could not be resolved uniquely.
+type AmbiguousMatch struct {
+	Device     string   `json:"device"`     // Name the device would have been given
+	MatchKey   string   `json:"matchKey"`   // The identity rule that found several candidates
+	Candidates []string `json:"candidates"` // UIDs of the matching Devices
+}
+
+// SourceAddress returns where the snapshot was collected from: spec.source, or
+// the provenance source once the reconciler has copied it into status.
+func (r *DiscoverySnapshot) SourceAddress() string {
+	if r.Spec.Source != "" {
+		return r.Spec.Source
+	}
+	if r.Status.Provenance != nil {
+		return r.Status.Provenance.Source
+	}
+	return ""
+}
+
+// CollectedAt returns when the snapshot's data was gathered: the provenance
+// completion time if known, otherwise when the snapshot was created.
+func (r *DiscoverySnapshot) CollectedAt() time.Time {
+	if p := r.Status.Provenance; p != nil && !p.CompletedAt.IsZero() {
+		return p.CompletedAt
+	}
+	return r.Metadata.CreatedAt
 }
 
-// Validate is a hook for custom validation logic
+// Validate is a hook for custom validation logic.
+// It rejects payloads whose format has no registered decoder, or that the
+// decoder cannot read, so bad snapshots fail at POST rather than in the reconciler.
 func (r *DiscoverySnapshot) Validate(ctx context.Context) error {
-	// You can add logic here to ensure RawData is valid JSON, etc.
+	if err := snapshotformat.Validate(r.Spec.RawData); err != nil {
+		return fmt.Errorf("spec.rawData: %w", err)
+	}
 	return nil
 }
 
@@ -0,0 +1,111 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package discoverysnapshot
+
+import (
+	"fmt"
+
+	"github.com/openchami/fabrica/pkg/resource"
+)
+
+// Snapshot phases.
+//
+//	Pending ──> Processing ──> Complete
+//	   ^            │
+//	   │            ├────────> Error
+//	   └────────────┘ (transient error, retry)
+//
+// Complete and Error are final until a reprocess moves them back to Pending.
+const (
+	PhasePending    = "Pending"
+	PhaseProcessing = "Processing"
+	PhaseComplete   = "Complete"
+	PhaseError      = "Error"
+)
+
+// phaseTransitions lists the phases each phase may move to.
+var phaseTransitions = map[string][]string{
+	PhasePending: {PhaseProcessing},
+	// Processing -> Processing resumes an attempt that was interrupted (e.g. by a restart).
+	PhaseProcessing: {PhaseProcessing, PhaseComplete, PhaseError, PhasePending},
+	PhaseComplete:   {PhasePending},
+	PhaseError:      {PhasePending},
+}
+
+// Condition types set on a DiscoverySnapshot.
+const (
+	// ConditionProcessed is True once the snapshot has been applied to the inventory.
+	ConditionProcessed = "Processed"
+	// ConditionDevicesMatched is False when some entries matched more than one Device.
+	ConditionDevicesMatched = "DevicesMatched"
+	// ConditionCollectionComplete is False when the collector reported endpoints
+	// it could not read, and Unknown when the payload carried no provenance.
+	ConditionCollectionComplete = "CollectionComplete"
+)
+
+// Condition reasons.
+const (
+	ReasonQueued             = "Queued"
+	ReasonReprocessRequested = "ReprocessRequested"
+	ReasonProcessing         = "Processing"
+	ReasonApplied            = "Applied"
+	ReasonTransientError     = "TransientError"
+	ReasonRetriesExhausted   = "RetriesExhausted"
+	ReasonApplyFailed        = "ApplyFailed"
+	ReasonAllMatched         = "AllMatched"
+	ReasonAmbiguousMatches   = "AmbiguousMatches"
+	ReasonNoWarnings         = "NoWarnings"
+	ReasonCollectionWarnings = "CollectionWarnings"
+	ReasonNoProvenance       = "NoProvenance"
+)
+
+// CurrentPhase returns the phase, treating a snapshot that has never been
+// touched by the reconciler as Pending.
+func (s *DiscoverySnapshotStatus) CurrentPhase() string {
+	if s.Phase == "" {
+		return PhasePending
+	}
+	return s.Phase
+}
+
+// CanTransition reports whether the lifecycle allows moving from one phase to another.
+func CanTransition(from, to string) bool {
+	if from == "" {
+		from = PhasePending
+	}
+	for _, allowed := range phaseTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// SetPhase moves the snapshot to phase with a human-readable message, and
+// refuses transitions the lifecycle does not allow.
+func (s *DiscoverySnapshotStatus) SetPhase(phase, message string) error {
+	if !CanTransition(s.Phase, phase) {
+		return fmt.Errorf("invalid phase transition %s -> %s", s.CurrentPhase(), phase)
+	}
+	s.Phase = phase
+	s.Message = message
+	return nil
+}
+
+// SetCondition records a condition on the status. LastTransitionTime only moves
+// when the condition's status changes.
+func (s *DiscoverySnapshotStatus) SetCondition(conditionType, status, reason, message string) {
+	resource.SetCondition(&s.Conditions, conditionType, status, reason, message)
+}
+
+// GetKind returns the resource kind; used by reconcile.BaseReconciler.UpdateStatus.
+func (r *DiscoverySnapshot) GetKind() string {
+	return "DiscoverySnapshot"
+}
+
+// GetConditions exposes the status conditions (resource.ResourceWithConditions).
+func (r *DiscoverySnapshot) GetConditions() *[]resource.Condition {
+	return &r.Status.Conditions
+}
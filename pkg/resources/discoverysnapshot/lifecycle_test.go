@@ -0,0 +1,56 @@
+package discoverysnapshot
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{"", PhaseProcessing, true},
+		{PhasePending, PhaseProcessing, true},
+		{PhasePending, PhaseComplete, false},
+		{PhaseProcessing, PhaseProcessing, true},
+		{PhaseProcessing, PhasePending, true},
+		{PhaseProcessing, PhaseComplete, true},
+		{PhaseProcessing, PhaseError, true},
+		{PhaseComplete, PhasePending, true},
+		{PhaseComplete, PhaseProcessing, false},
+		{PhaseError, PhaseComplete, false},
+		{PhaseError, PhasePending, true},
+	}
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%q, %q) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestSetPhase(t *testing.T) {
+	var status DiscoverySnapshotStatus
+	if status.CurrentPhase() != PhasePending {
+		t.Errorf("new snapshot phase = %s, want Pending", status.CurrentPhase())
+	}
+	if err := status.SetPhase(PhaseComplete, "done"); err == nil {
+		t.Error("Pending -> Complete allowed, want an error")
+	}
+	if err := status.SetPhase(PhaseProcessing, "working"); err != nil {
+		t.Fatal(err)
+	}
+	if status.Phase != PhaseProcessing || status.Message != "working" {
+		t.Errorf("status = %s %q, want Processing \"working\"", status.Phase, status.Message)
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	var status DiscoverySnapshotStatus
+	status.SetCondition(ConditionProcessed, "False", ReasonProcessing, "")
+	first := status.Conditions[0].LastTransitionTime
+	status.SetCondition(ConditionProcessed, "False", ReasonTransientError, "disk full")
+	if len(status.Conditions) != 1 {
+		t.Fatalf("conditions = %+v, want one", status.Conditions)
+	}
+	if c := status.Conditions[0]; c.Reason != ReasonTransientError || !c.LastTransitionTime.Equal(first) {
+		t.Errorf("condition = %+v, want the reason updated and the transition time kept", c)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package retention garbage-collects DiscoverySnapshots. Every collector run
+// creates a snapshot holding its full payload, so without a policy the data
+// directory grows forever.
+//
+// A snapshot is kept while it is among the newest KeepLast snapshots for its
+// source, or younger than KeepFor. Past that it is deleted, or, with
+// StripRawData, Complete snapshots keep their status (summary, results,
+// provenance) and only lose spec.rawData. Pending and Processing snapshots are
+// never touched.
+package retention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+)
+
+// Policy decides which snapshots to keep.
+type Policy struct {
+	KeepLast     int           // Newest snapshots kept per source
+	KeepFor      time.Duration // Snapshots collected more recently than this are kept
+	StripRawData bool          // Strip old Complete snapshots instead of deleting them
+}
+
+// Validate refuses a policy that would keep nothing.
+func (p Policy) Validate() error {
+	if p.KeepLast < 0 || p.KeepFor < 0 {
+		return fmt.Errorf("retention limits must not be negative")
+	}
+	if p.KeepLast == 0 && p.KeepFor == 0 {
+		return fmt.Errorf("retention policy keeps nothing; set a keep-last count or a keep-days age")
+	}
+	return nil
+}
+
+// Action is what the policy does to an expired snapshot.
+type Action string
+
+const (
+	ActionDelete Action = "delete"
+	ActionStrip  Action = "strip"
+)
+
+// Decision is one snapshot the policy expires.
+type Decision struct {
+	UID         string    `json:"uid"`
+	Name        string    `json:"name"`
+	Source      string    `json:"source"`
+	Phase       string    `json:"phase"`
+	CollectedAt time.Time `json:"collectedAt"`
+	Action      Action    `json:"action"`
+}
+
+// String renders the decision for logs and the gc command.
+func (d Decision) String() string {
+	return fmt.Sprintf("%s %s (%s, source %q, %s, collected %s)",
+		d.Action, d.UID, d.Name, d.Source, d.Phase, d.CollectedAt.Format(time.RFC3339))
+}
+
+// Plan returns the snapshots that have expired under the policy at now.
+func (p Policy) Plan(snapshots []*discoverysnapshot.DiscoverySnapshot, now time.Time) []Decision {
+	bySource := make(map[string][]*discoverysnapshot.DiscoverySnapshot)
+	for _, s := range snapshots {
+		bySource[s.SourceAddress()] = append(bySource[s.SourceAddress()], s)
+	}
+
+	var decisions []Decision
+	for source, group := range bySource {
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].CollectedAt().After(group[j].CollectedAt())
+		})
+		for i, s := range group {
+			if i < p.KeepLast || (p.KeepFor > 0 && now.Sub(s.CollectedAt()) < p.KeepFor) {
+				continue
+			}
+			action, ok := p.expire(s)
+			if !ok {
+				continue
+			}
+			decisions = append(decisions, Decision{
+				UID:         s.GetUID(),
+				Name:        s.GetName(),
+				Source:      source,
+				Phase:       s.Status.CurrentPhase(),
+				CollectedAt: s.CollectedAt(),
+				Action:      action,
+			})
+		}
+	}
+	sort.Slice(decisions, func(i, j int) bool { return decisions[i].CollectedAt.Before(decisions[j].CollectedAt) })
+	return decisions
+}
+
+// expire returns what to do with a snapshot past the retention limits, or
+// false to leave it alone.
+func (p Policy) expire(s *discoverysnapshot.DiscoverySnapshot) (Action, bool) {
+	switch s.Status.CurrentPhase() {
+	case discoverysnapshot.PhasePending, discoverysnapshot.PhaseProcessing:
+		return "", false
+	case discoverysnapshot.PhaseComplete:
+		if p.StripRawData {
+			return ActionStrip, s.Status.RawDataStrippedAt == nil
+		}
+	}
+	return ActionDelete, true
+}
+
+// Sweep loads every snapshot, plans against the policy and, unless dryRun is
+// set, carries the plan out. It returns the decisions it acted on (or would
+// have); a failure on one snapshot does not stop the others.
+func Sweep(ctx context.Context, p Policy, now time.Time, dryRun bool) ([]Decision, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	snapshots, err := storage.LoadAllDiscoverySnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	decisions := p.Plan(snapshots, now)
+	if dryRun {
+		return decisions, nil
+	}
+
+	done := make([]Decision, 0, len(decisions))
+	var errs []error
+	for _, d := range decisions {
+		if err := apply(ctx, d, now); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", d.UID, err))
+			continue
+		}
+		done = append(done, d)
+	}
+	return done, errors.Join(errs...)
+}
+
+// apply carries out one decision. The snapshot is reloaded first so one that
+// was requeued since the plan was made is left alone.
+func apply(ctx context.Context, d Decision, now time.Time) error {
+	s, err := storage.LoadDiscoverySnapshot(ctx, d.UID)
+	if err != nil {
+		return err
+	}
+	if s.Status.CurrentPhase() != d.Phase {
+		return fmt.Errorf("phase changed from %s to %s, skipped", d.Phase, s.Status.CurrentPhase())
+	}
+
+	switch d.Action {
+	case ActionDelete:
+		return storage.DeleteDiscoverySnapshot(ctx, d.UID)
+	case ActionStrip:
+		s.Spec.RawData = nil
+		s.Status.RawDataStrippedAt = &now
+		s.Touch()
+		return storage.SaveDiscoverySnapshot(ctx, s)
+	}
+	return fmt.Errorf("unknown retention action %q", d.Action)
+}
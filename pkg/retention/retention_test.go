@@ -0,0 +1,204 @@
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	fabricaStorage "github.com/openchami/fabrica/pkg/storage"
+
+	"github.com/user/inventory-api/internal/storage"
+	"github.com/user/inventory-api/pkg/resources/discoverysnapshot"
+)
+
+var now = time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+// snap builds a snapshot collected daysAgo days before now.
+func snap(uid, source, phase string, daysAgo int) *discoverysnapshot.DiscoverySnapshot {
+	s := &discoverysnapshot.DiscoverySnapshot{
+		Spec: discoverysnapshot.DiscoverySnapshotSpec{Source: source, RawData: json.RawMessage(`[]`)},
+	}
+	s.Metadata.Initialize("snapshot-"+uid, uid)
+	s.Metadata.CreatedAt = now.AddDate(0, 0, -daysAgo)
+	s.Status.Phase = phase
+	return s
+}
+
+// decisionList renders decisions as "uid:action", sorted by UID.
+func decisionList(decisions []Decision) string {
+	out := make([]string, 0, len(decisions))
+	for _, d := range decisions {
+		out = append(out, d.UID+":"+string(d.Action))
+	}
+	sort.Strings(out)
+	return strings.Join(out, " ")
+}
+
+func TestPlan(t *testing.T) {
+	const day = 24 * time.Hour
+	stripped := snap("c4", "10.0.0.3", discoverysnapshot.PhaseComplete, 40)
+	stripped.Status.RawDataStrippedAt = &now
+
+	snapshots := []*discoverysnapshot.DiscoverySnapshot{
+		snap("a1", "10.0.0.1", discoverysnapshot.PhaseComplete, 1),
+		snap("a2", "10.0.0.1", discoverysnapshot.PhaseComplete, 10),
+		snap("a3", "10.0.0.1", discoverysnapshot.PhaseError, 20),
+		snap("a4", "10.0.0.1", discoverysnapshot.PhaseComplete, 30),
+		snap("b1", "10.0.0.2", discoverysnapshot.PhaseComplete, 50),
+		snap("c1", "10.0.0.3", discoverysnapshot.PhaseComplete, 5),
+		snap("c2", "10.0.0.3", discoverysnapshot.PhasePending, 35),
+		snap("c3", "10.0.0.3", discoverysnapshot.PhaseProcessing, 36),
+		stripped,
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   string
+	}{
+		{
+			name:   "keep last per source",
+			policy: Policy{KeepLast: 1},
+			want:   "a2:delete a3:delete a4:delete c4:delete",
+		},
+		{
+			name:   "keep for",
+			policy: Policy{KeepFor: 15 * day},
+			want:   "a3:delete a4:delete b1:delete c4:delete",
+		},
+		{
+			name:   "keep last or keep for, whichever keeps more",
+			policy: Policy{KeepLast: 2, KeepFor: 25 * day},
+			want:   "a4:delete c4:delete",
+		},
+		{
+			name:   "strip complete, delete the rest",
+			policy: Policy{KeepLast: 1, StripRawData: true},
+			want:   "a2:strip a3:delete a4:strip",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decisionList(tt.policy.Plan(snapshots, now)); got != tt.want {
+				t.Errorf("Plan = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanOrder(t *testing.T) {
+	decisions := Policy{KeepLast: 1}.Plan([]*discoverysnapshot.DiscoverySnapshot{
+		snap("new", "10.0.0.1", discoverysnapshot.PhaseComplete, 1),
+		snap("mid", "10.0.0.1", discoverysnapshot.PhaseComplete, 5),
+		snap("old", "10.0.0.1", discoverysnapshot.PhaseComplete, 9),
+	}, now)
+	if len(decisions) != 2 || decisions[0].UID != "old" || decisions[1].UID != "mid" {
+		t.Errorf("Plan = %v, want old then mid", decisions)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		policy Policy
+		ok     bool
+	}{
+		{Policy{KeepLast: 3}, true},
+		{Policy{KeepFor: time.Hour}, true},
+		{Policy{StripRawData: true}, false},
+		{Policy{KeepLast: -1, KeepFor: time.Hour}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err == nil) != tt.ok {
+			t.Errorf("%+v Validate = %v, want ok %v", tt.policy, err, tt.ok)
+		}
+	}
+}
+
+func TestSweep(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  Policy
+		dryRun  bool
+		remain  string // UIDs left in storage
+		strip   string // UIDs left without raw data
+		decided string
+	}{
+		{
+			name:    "dry run",
+			policy:  Policy{KeepLast: 1},
+			dryRun:  true,
+			remain:  "a1 a2 a3 p1",
+			decided: "a2:delete a3:delete",
+		},
+		{
+			name:    "delete",
+			policy:  Policy{KeepLast: 1},
+			remain:  "a1 p1",
+			decided: "a2:delete a3:delete",
+		},
+		{
+			name:    "strip",
+			policy:  Policy{KeepLast: 1, StripRawData: true},
+			remain:  "a1 a2 p1",
+			strip:   "a2",
+			decided: "a2:strip a3:delete",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			backend, err := fabricaStorage.NewFileBackend(t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			storage.Init(backend)
+			for _, s := range []*discoverysnapshot.DiscoverySnapshot{
+				snap("a1", "10.0.0.1", discoverysnapshot.PhaseComplete, 1),
+				snap("a2", "10.0.0.1", discoverysnapshot.PhaseComplete, 10),
+				snap("a3", "10.0.0.1", discoverysnapshot.PhaseError, 20),
+				snap("p1", "10.0.0.1", discoverysnapshot.PhasePending, 30),
+			} {
+				if err := storage.SaveDiscoverySnapshot(ctx, s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, _ := storage.LoadDiscoverySnapshot(ctx, "a2")
+
+			decisions, err := Sweep(ctx, tt.policy, now, tt.dryRun)
+			if err != nil {
+				t.Fatalf("Sweep: %v", err)
+			}
+			if got := decisionList(decisions); got != tt.decided {
+				t.Errorf("Sweep = %s, want %s", got, tt.decided)
+			}
+
+			left, err := storage.LoadAllDiscoverySnapshots(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var remain, strip []string
+			for _, s := range left {
+				remain = append(remain, s.GetUID())
+				if s.Status.RawDataStrippedAt != nil || s.Spec.RawData == nil {
+					strip = append(strip, s.GetUID())
+				}
+			}
+			sort.Strings(remain)
+			if got := strings.Join(remain, " "); got != tt.remain {
+				t.Errorf("left %s, want %s", got, tt.remain)
+			}
+			if got := strings.Join(strip, " "); got != tt.strip {
+				t.Errorf("stripped %s, want %s", got, tt.strip)
+			}
+			if tt.dryRun {
+				after, _ := storage.LoadDiscoverySnapshot(ctx, "a2")
+				if !after.Metadata.UpdatedAt.Equal(before.Metadata.UpdatedAt) {
+					t.Errorf("dry run touched a2")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+// Package snapshotformat decodes DiscoverySnapshot payloads into devices.
+//
+// A payload is a versioned envelope:
+//
+//	{"format": "redfish-collector/v1", "provenance": {...}, "data": ...}
+//
+// Each format has a Decoder registered under its name. Adding a discovery
+// source means registering a new Decoder; the reconciler does not change.
+// A bare JSON array, which older collectors posted, is read as
+// redfish-collector/v1. Provenance is optional and describes the collection
+// run that produced the data.
+package snapshotformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// PropDiscoveryRef is set by decoders whose payloads have no Redfish URIs, so
+// components without serial numbers can still be matched on re-ingest (the
+// discovery_ref identity rule).
+const PropDiscoveryRef = "discovery_ref"
+
+// Envelope is the outer structure of a snapshot payload.
+type Envelope struct {
+	Format     string          `json:"format"`
+	Provenance *Provenance     `json:"provenance,omitempty"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Entry is one device decoded from a payload.
+type Entry struct {
+	Status *device.DeviceStatus
+
+	// Ref names the entry within the payload and ParentRef points at the Ref of
+	// its parent, if any. The reconciler turns these into ParentIDs. Redfish
+	// payloads use resource URIs; other formats choose their own scheme.
+	Ref       string
+	ParentRef string
+}
+
+// Decoder turns the data of one payload format into device entries.
+type Decoder interface {
+	Decode(data json.RawMessage) ([]Entry, error)
+}
+
+// DecoderFunc adapts a function to the Decoder interface.
+type DecoderFunc func(data json.RawMessage) ([]Entry, error)
+
+// Decode calls f(data).
+func (f DecoderFunc) Decode(data json.RawMessage) ([]Entry, error) {
+	return f(data)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Decoder)
+)
+
+// Register makes a decoder available for format. It panics on a duplicate
+// registration, which is always a programming error.
+func Register(format string, dec Decoder) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[format]; exists {
+		panic(fmt.Sprintf("snapshotformat: decoder for %q registered twice", format))
+	}
+	registry[format] = dec
+}
+
+// Lookup returns the decoder registered for format.
+func Lookup(format string) (Decoder, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	dec, ok := registry[format]
+	return dec, ok
+}
+
+// Formats lists the registered format names in sorted order.
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	formats := make([]string, 0, len(registry))
+	for format := range registry {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Parse reads the envelope of a payload. A bare array is wrapped as
+// redfish-collector/v1.
+func Parse(raw json.RawMessage) (*Envelope, error) {
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 {
+		return nil, fmt.Errorf("payload is empty")
+	}
+	if trimmed[0] == '[' {
+		return &Envelope{Format: FormatRedfishCollectorV1, Data: raw}, nil
+	}
+
+	env := &Envelope{}
+	if err := json.Unmarshal(trimmed, env); err != nil {
+		return nil, fmt.Errorf("payload is not a snapshot envelope: %w", err)
+	}
+	if env.Format == "" {
+		return nil, fmt.Errorf("payload has no format")
+	}
+	return env, nil
+}
+
+// Decode parses the envelope and dispatches to the decoder for its format.
+func Decode(raw json.RawMessage) (*Envelope, []Entry, error) {
+	env, err := Parse(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	dec, ok := Lookup(env.Format)
+	if !ok {
+		return env, nil, fmt.Errorf("unknown payload format %q (known: %v)", env.Format, Formats())
+	}
+	entries, err := dec.Decode(env.Data)
+	if err != nil {
+		return env, nil, fmt.Errorf("failed to decode %s payload: %w", env.Format, err)
+	}
+	return env, entries, nil
+}
+
+// Validate checks that a payload has a known format and decodes cleanly to
+// at least one device.
+func Validate(raw json.RawMessage) error {
+	env, entries, err := Decode(raw)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s payload has no devices", env.Format)
+	}
+	return nil
+}
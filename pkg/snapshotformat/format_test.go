@@ -0,0 +1,126 @@
+package snapshotformat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		format  string
+		entries int
+		wantErr string
+	}{
+		{
+			name:    "envelope",
+			raw:     `{"format": "redfish-collector/v1", "data": [{"deviceType": "Node", "properties": {"redfish_uri": "/Systems/1"}}]}`,
+			format:  FormatRedfishCollectorV1,
+			entries: 1,
+		},
+		{
+			name:    "bare array",
+			raw:     `[{"deviceType": "Node"}, null, {"deviceType": "CPU"}]`,
+			format:  FormatRedfishCollectorV1,
+			entries: 2,
+		},
+		{
+			name:    "unknown format",
+			raw:     `{"format": "ipmitool/v9", "data": []}`,
+			wantErr: `unknown payload format "ipmitool/v9"`,
+		},
+		{
+			name:    "no format",
+			raw:     `{"data": []}`,
+			wantErr: "payload has no format",
+		},
+		{
+			name:    "null",
+			raw:     `null`,
+			wantErr: "payload has no format",
+		},
+		{
+			name:    "empty",
+			raw:     ` `,
+			wantErr: "payload is empty",
+		},
+		{
+			name:    "data of the wrong shape",
+			raw:     `{"format": "redfish-collector/v1", "data": {"not": "a list"}}`,
+			wantErr: "failed to decode redfish-collector/v1 payload",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env, entries, err := Decode(json.RawMessage(tt.raw))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Decode error = %v, want %q", err, tt.wantErr)
+				}
+				if Validate(json.RawMessage(tt.raw)) == nil {
+					t.Errorf("Validate accepted the payload")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if env.Format != tt.format || len(entries) != tt.entries {
+				t.Errorf("Decode = %s with %d entries, want %s with %d", env.Format, len(entries), tt.format, tt.entries)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		wantErr string
+	}{
+		{"devices", `[{"deviceType": "Node"}]`, ""},
+		{"empty array", `[]`, "redfish-collector/v1 payload has no devices"},
+		{"only nulls", `[null]`, "redfish-collector/v1 payload has no devices"},
+		{"envelope with no devices", `{"format": "redfish-collector/v1", "data": []}`, "redfish-collector/v1 payload has no devices"},
+		{"unknown format", `{"format": "ipmitool/v9", "data": []}`, `unknown payload format "ipmitool/v9"`},
+	}
+	for _, tt := range tests {
+		err := Validate(json.RawMessage(tt.raw))
+		if tt.wantErr == "" {
+			if err != nil {
+				t.Errorf("%s: Validate = %v, want nil", tt.name, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Validate = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDecodeRedfishRefs(t *testing.T) {
+	_, entries, err := Decode(json.RawMessage(`[{"deviceType": "CPU", "properties": {"redfish_uri": "/Systems/1/Processors/CPU1", "redfish_parent_uri": "/Systems/1"}}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := entries[0]; got.Ref != "/Systems/1/Processors/CPU1" || got.ParentRef != "/Systems/1" {
+		t.Errorf("entry refs = %q, %q", got.Ref, got.ParentRef)
+	}
+}
+
+func TestRegisterTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("second Register did not panic")
+		}
+	}()
+	Register(FormatRedfishCollectorV1, DecoderFunc(decodeRedfishCollectorV1))
+}
+
+func TestFormats(t *testing.T) {
+	got := strings.Join(Formats(), " ")
+	if want := "hpcm-node/v1 lshw/v1 redfish-collector/v1"; got != want {
+		t.Errorf("Formats = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package snapshotformat
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// FormatHPCMNodeV1 is a single HPCM node record, as returned by the HPCM API
+// (see test-data/node_data.json). The mapping follows test-data/populate_node.sh.
+const FormatHPCMNodeV1 = "hpcm-node/v1"
+
+func init() {
+	Register(FormatHPCMNodeV1, DecoderFunc(decodeHPCMNodeV1))
+}
+
+// hpcmNode holds the parts of an HPCM node record we map.
+type hpcmNode struct {
+	Name       string                 `json:"name"`
+	UUID       string                 `json:"uuid"`
+	Aliases    map[string]interface{} `json:"aliases"`
+	Network    map[string]interface{} `json:"network"`
+	Image      map[string]interface{} `json:"image"`
+	Platform   map[string]interface{} `json:"platform"`
+	Management map[string]interface{} `json:"management"`
+	Attributes map[string]interface{} `json:"attributes"`
+	Inventory  map[string]interface{} `json:"inventory"`
+}
+
+// hpcmComponentPrefixes are inventory keys that become child devices rather than node properties.
+var hpcmComponentPrefixes = []string{"cpu.", "dimm.", "nic.", "disk."}
+
+func decodeHPCMNodeV1(data json.RawMessage) ([]Entry, error) {
+	var node hpcmNode
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	if node.UUID == "" {
+		return nil, fmt.Errorf("node %q has no uuid", node.Name)
+	}
+	inv := node.Inventory
+	nodeRef := "nodes/" + node.UUID
+
+	// --- Node ---
+	nodeStatus := &device.DeviceStatus{
+		DeviceType:   "Node",
+		Manufacturer: inventoryString(inv, "sys.Manufacturer"),
+		SerialNumber: inventoryString(inv, "sys.Serial Number"),
+		PartNumber:   inventoryString(inv, "fru.system.SKU"),
+	}
+	SetProperty(nodeStatus, "old_uuid", node.UUID)
+	SetProperty(nodeStatus, PropDiscoveryRef, nodeRef)
+	SetProperty(nodeStatus, "hostname", node.Name)
+	SetProperty(nodeStatus, "aliases", SnakeKeys(node.Aliases))
+	SetProperty(nodeStatus, "network", SnakeKeys(node.Network))
+	SetProperty(nodeStatus, "image", SnakeKeys(node.Image))
+	SetProperty(nodeStatus, "platform", SnakeKeys(node.Platform))
+	if node.Management != nil {
+		delete(node.Management, "password") // Never copy BMC credentials into the inventory
+		SetProperty(nodeStatus, "management", SnakeKeys(node.Management))
+	}
+	SetProperty(nodeStatus, "attributes", SnakeKeys(node.Attributes))
+
+	nodeInventory := make(map[string]interface{})
+	for key, value := range inv {
+		if !hasAnyPrefix(key, hpcmComponentPrefixes) {
+			nodeInventory[inventoryKey(key)] = value
+		}
+	}
+	if len(nodeInventory) > 0 {
+		SetProperty(nodeStatus, "inventory", nodeInventory)
+	}
+
+	entries := []Entry{{Status: nodeStatus, Ref: nodeRef}}
+	child := func(kind, id string, status *device.DeviceStatus) {
+		ref := fmt.Sprintf("%s/%s/%s", nodeRef, kind, id)
+		SetProperty(status, PropDiscoveryRef, ref)
+		entries = append(entries, Entry{Status: status, Ref: ref, ParentRef: nodeRef})
+	}
+
+	// --- CPUs ---
+	for _, id := range inventoryIDs(inv, "cpu.") {
+		serial := inventoryString(inv, "cpu."+id+".Serial Number")
+		if serial == "" {
+			continue
+		}
+		status := &device.DeviceStatus{
+			DeviceType:   "CPU",
+			Manufacturer: inventoryString(inv, "cpu."+id+".Manufacturer"),
+			SerialNumber: serial,
+		}
+		SetProperty(status, "processor_id", id)
+		SetProperty(status, "version", inventoryString(inv, "cpu."+id+".Version"))
+		child("cpu", id, status)
+	}
+
+	// --- DIMMs ---
+	for _, id := range inventoryIDs(inv, "dimm.") {
+		status := &device.DeviceStatus{
+			DeviceType:   "DIMM",
+			Manufacturer: inventoryString(inv, "dimm."+id+".Manufacturer"),
+			SerialNumber: inventoryString(inv, "dimm."+id+".Serial Number"),
+		}
+		SetProperty(status, "dimm_id", id)
+		child("dimm", id, status)
+	}
+
+	// --- NICs (from network.nics; the MAC is the serial number) ---
+	nics, _ := node.Network["nics"].([]interface{})
+	for _, raw := range nics {
+		nic, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name, _ := nic["name"].(string)
+		mac, _ := nic["macAddress"].(string)
+		if name == "" {
+			continue
+		}
+		status := &device.DeviceStatus{
+			DeviceType:   "NIC",
+			Manufacturer: inventoryStringOr(inv, "nic."+name+".manufacturer", "Unknown"),
+			SerialNumber: mac,
+		}
+		if props, ok := SnakeKeys(nic).(map[string]interface{}); ok {
+			for key, value := range props {
+				SetProperty(status, key, value)
+			}
+		}
+		child("nic", name, status)
+	}
+
+	// --- Disks ---
+	for _, id := range inventoryIDs(inv, "disk.") {
+		status := &device.DeviceStatus{
+			DeviceType: "Disk",
+			// HPCM rarely reports a disk vendor; "Unknown" keeps serial matching usable.
+			Manufacturer: inventoryStringOr(inv, "disk."+id+".manufacturer", "Unknown"),
+			SerialNumber: inventoryString(inv, "disk."+id+".serial_number"),
+		}
+		SetProperty(status, "disk_id", id)
+		child("disk", id, status)
+	}
+
+	return entries, nil
+}
+
+// inventoryIDs returns the sorted, distinct component IDs under prefix, e.g.
+// "cpu.Proc 1.Serial Number" -> "Proc 1".
+func inventoryIDs(inv map[string]interface{}, prefix string) []string {
+	seen := make(map[string]bool)
+	for key := range inv {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		rest := strings.TrimPrefix(key, prefix)
+		if i := strings.Index(rest, "."); i > 0 {
+			seen[rest[:i]] = true
+		}
+	}
+	ids := make([]string, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func inventoryString(inv map[string]interface{}, key string) string {
+	s, _ := inv[key].(string)
+	return s
+}
+
+func inventoryStringOr(inv map[string]interface{}, key, fallback string) string {
+	if s := inventoryString(inv, key); s != "" {
+		return s
+	}
+	return fallback
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// inventoryKey converts "Product Name" to "product_name" (populate_node.sh's to_inventory_key).
+func inventoryKey(s string) string {
+	return SnakeCase(strings.ReplaceAll(s, " ", "_"))
+}
+
+// SnakeKeys applies snakeCase to every object key, recursively, so
+// structured values follow the same key rules as the properties holding them.
+func SnakeKeys(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		if val == nil {
+			return nil
+		}
+		out := make(map[string]interface{}, len(val))
+		for key, item := range val {
+			out[SnakeCase(key)] = SnakeKeys(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = SnakeKeys(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
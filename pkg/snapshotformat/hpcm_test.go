@@ -0,0 +1,119 @@
+package snapshotformat
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// decodeFile decodes a fixture as the data of format.
+func decodeFile(t *testing.T, format, path string) []Entry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(Envelope{Format: format, Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, entries, err := Decode(raw)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return entries
+}
+
+// wantEntry is the part of a decoded Entry a test checks.
+type wantEntry struct {
+	deviceType, manufacturer, serial, ref, parentRef string
+}
+
+func checkEntries(t *testing.T, entries []Entry, want []wantEntry) {
+	t.Helper()
+	if len(entries) != len(want) {
+		t.Errorf("decoded %d entries, want %d", len(entries), len(want))
+	}
+	for i := 0; i < len(entries) && i < len(want); i++ {
+		e := entries[i]
+		got := wantEntry{e.Status.DeviceType, e.Status.Manufacturer, e.Status.SerialNumber, e.Ref, e.ParentRef}
+		if got != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, got, want[i])
+		}
+		if ref := StringProperty(e.Status, PropDiscoveryRef); ref != e.Ref {
+			t.Errorf("entry %d %s = %q, want its Ref %q", i, PropDiscoveryRef, ref, e.Ref)
+		}
+	}
+}
+
+func TestDecodeHPCMNode(t *testing.T) {
+	entries := decodeFile(t, FormatHPCMNodeV1, "../../test-data/node_data.json")
+	node := "nodes/11111111-aaaa-bbbb-cccc-000000000001"
+	checkEntries(t, entries, []wantEntry{
+		{"Node", "Vendor Inc.", "SN-SYS-12345", node, ""},
+		{"CPU", "CPU Vendor A", "SN-CPU-001", node + "/cpu/Proc 1", node},
+		{"CPU", "CPU Vendor A", "SN-CPU-002", node + "/cpu/Proc 2", node},
+		{"DIMM", "Memory Vendor B", "SN-DIMM-001", node + "/dimm/0000000A", node},
+		{"DIMM", "Memory Vendor B", "SN-DIMM-002", node + "/dimm/0000000B", node},
+		{"NIC", "Network Vendor C", "0A:0B:0C:0D:0E:01", node + "/nic/eno1", node},
+		{"NIC", "Unknown", "0A:0B:0C:0D:0E:02", node + "/nic/bmc0", node},
+		{"Disk", "Unknown", "SN-DISK-AAAAA", node + "/disk/disk0", node},
+	})
+
+	props := entries[0].Status.Properties
+	if got := StringProperty(entries[0].Status, "old_uuid"); got != "11111111-aaaa-bbbb-cccc-000000000001" {
+		t.Errorf("old_uuid = %q", got)
+	}
+	if got := StringProperty(entries[0].Status, "hostname"); got != "compute-node-01" {
+		t.Errorf("hostname = %q", got)
+	}
+	for key, raw := range props {
+		if strings.Contains(strings.ToLower(string(raw)), "password") {
+			t.Errorf("property %s carries a BMC password: %s", key, raw)
+		}
+	}
+	var management map[string]interface{}
+	if err := json.Unmarshal(props["management"], &management); err != nil {
+		t.Fatal(err)
+	}
+	if management["card_ip_address"] != "192.168.2.10" {
+		t.Errorf("management = %v, want snake_case keys", management)
+	}
+	var inventory map[string]interface{}
+	if err := json.Unmarshal(props["inventory"], &inventory); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := inventory["sys.product_name"]; !ok {
+		t.Errorf("inventory = %v, want sys.product_name", inventory)
+	}
+	for key := range inventory {
+		if hasAnyPrefix(key, hpcmComponentPrefixes) {
+			t.Errorf("inventory has component key %s", key)
+		}
+	}
+}
+
+func TestDecodeHPCMNodeWithoutUUID(t *testing.T) {
+	_, _, err := Decode(json.RawMessage(`{"format": "hpcm-node/v1", "data": {"name": "n1"}}`))
+	if err == nil || !strings.Contains(err.Error(), "no uuid") {
+		t.Errorf("Decode error = %v, want a missing uuid error", err)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"macAddress":   "mac_address",
+		"ipv6Address":  "ipv6_address",
+		"cardType":     "card_type",
+		"already_done": "already_done",
+	}
+	for in, want := range tests {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+	if got := inventoryKey("Product Name"); got != "product_name" {
+		t.Errorf("inventoryKey = %q, want product_name", got)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package snapshotformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// FormatLSHWV1 is the output of `lshw -json` run on a node, either the bare
+// object or the one-element array newer lshw versions print.
+const FormatLSHWV1 = "lshw/v1"
+
+func init() {
+	Register(FormatLSHWV1, DecoderFunc(decodeLSHWV1))
+}
+
+// lshwNode is one node of the lshw tree.
+type lshwNode struct {
+	ID          string      `json:"id"`
+	Class       string      `json:"class"`
+	Description string      `json:"description"`
+	Product     string      `json:"product"`
+	Vendor      string      `json:"vendor"`
+	Serial      string      `json:"serial"`
+	Slot        string      `json:"slot"`
+	BusInfo     string      `json:"businfo"`
+	LogicalName interface{} `json:"logicalname"` // string or []string
+	Size        *uint64     `json:"size"`
+	Disabled    bool        `json:"disabled"`
+	Children    []lshwNode  `json:"children"`
+}
+
+func decodeLSHWV1(data json.RawMessage) ([]Entry, error) {
+	var root lshwNode
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var roots []lshwNode
+		if err := json.Unmarshal(trimmed, &roots); err != nil {
+			return nil, err
+		}
+		if len(roots) != 1 {
+			return nil, fmt.Errorf("expected one system, got %d", len(roots))
+		}
+		root = roots[0]
+	} else if err := json.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Class != "system" {
+		return nil, fmt.Errorf("top-level node has class %q, want \"system\"", root.Class)
+	}
+
+	nodeRef := root.ID
+	nodeStatus := &device.DeviceStatus{
+		DeviceType:   "Node",
+		Manufacturer: root.Vendor,
+		SerialNumber: root.Serial,
+	}
+	setLSHWProperties(nodeStatus, &root, nodeRef)
+	entries := []Entry{{Status: nodeStatus, Ref: nodeRef}}
+
+	// Components hang directly off the Node, as in the Redfish collector.
+	var walk func(n *lshwNode, path string)
+	walk = func(n *lshwNode, path string) {
+		for i := range n.Children {
+			c := &n.Children[i]
+			ref := path + "/" + c.ID
+			if deviceType := lshwDeviceType(c); deviceType != "" {
+				status := &device.DeviceStatus{
+					DeviceType:   deviceType,
+					Manufacturer: c.Vendor,
+					SerialNumber: c.Serial,
+				}
+				setLSHWProperties(status, c, ref)
+				entries = append(entries, Entry{Status: status, Ref: ref, ParentRef: nodeRef})
+			}
+			walk(c, ref)
+		}
+	}
+	walk(&root, nodeRef)
+	return entries, nil
+}
+
+// lshwDeviceType maps an lshw node to a device type, or "" to skip it.
+func lshwDeviceType(n *lshwNode) string {
+	if n.Disabled {
+		return ""
+	}
+	switch {
+	case n.Class == "processor":
+		return "CPU"
+	case n.Class == "memory" && strings.HasPrefix(n.ID, "bank:") && !strings.Contains(n.Description, "[empty]"):
+		return "DIMM"
+	case n.Class == "network":
+		return "NIC"
+	case n.Class == "disk" && !strings.HasPrefix(n.ID, "cdrom"):
+		return "Disk"
+	}
+	return ""
+}
+
+func setLSHWProperties(status *device.DeviceStatus, n *lshwNode, ref string) {
+	SetProperty(status, PropDiscoveryRef, ref)
+	SetProperty(status, "lshw.product", n.Product)
+	SetProperty(status, "lshw.description", n.Description)
+	SetProperty(status, "lshw.slot", n.Slot)
+	SetProperty(status, "lshw.businfo", n.BusInfo)
+	SetProperty(status, "lshw.logicalname", n.LogicalName)
+	if n.Size != nil {
+		SetProperty(status, "lshw.size", *n.Size)
+	}
+}
@@ -0,0 +1,41 @@
+package snapshotformat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLSHW(t *testing.T) {
+	entries := decodeFile(t, FormatLSHWV1, "testdata/lshw.json")
+	checkEntries(t, entries, []wantEntry{
+		{"Node", "HPE", "MXQ12345AB", "node01", ""},
+		{"CPU", "Intel Corp.", "CPU0-SERIAL", "node01/core/cpu:0", "node01"},
+		{"DIMM", "HPE", "DIMM0-SERIAL", "node01/core/memory/bank:0", "node01"},
+		{"NIC", "Intel Corporation", "3c:fd:fe:aa:bb:cc", "node01/core/pci:0/network", "node01"},
+		{"Disk", "HPE", "DISK0-SERIAL", "node01/core/pci:0/sata/disk", "node01"},
+	})
+
+	disk := entries[4].Status
+	if got := string(disk.Properties["lshw.logicalname"]); got != `["/dev/sda","/dev/sda1"]` {
+		t.Errorf("lshw.logicalname = %s", got)
+	}
+	if got := string(disk.Properties["lshw.size"]); got != "480103981056" {
+		t.Errorf("lshw.size = %s", got)
+	}
+}
+
+func TestDecodeLSHWErrors(t *testing.T) {
+	tests := []struct {
+		name, data, wantErr string
+	}{
+		{"not a system", `{"id": "cpu:0", "class": "processor"}`, `want "system"`},
+		{"several systems", `[{"id": "a", "class": "system"}, {"id": "b", "class": "system"}]`, "expected one system, got 2"},
+	}
+	for _, tt := range tests {
+		raw, _ := json.Marshal(Envelope{Format: FormatLSHWV1, Data: json.RawMessage(tt.data)})
+		if _, _, err := Decode(raw); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: Decode error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package snapshotformat
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// Device properties are shared by the decoders here, the collector that
+// produces redfish-collector/v1 payloads and the reconciler that matches on
+// them, so they read and write them with the same helpers.
+
+// StringProperty returns a string-valued property, or "" if absent or not a string.
+func StringProperty(status *device.DeviceStatus, key string) string {
+	var s string
+	if raw, ok := status.Properties[key]; ok {
+		_ = json.Unmarshal(raw, &s)
+	}
+	return s
+}
+
+// SetStringProperty stores a string property; "" is skipped.
+func SetStringProperty(status *device.DeviceStatus, key, value string) {
+	if value == "" {
+		return
+	}
+	SetProperty(status, key, value)
+}
+
+// SetProperty stores any JSON-encodable value; nil and "" are skipped.
+func SetProperty(status *device.DeviceStatus, key string, value interface{}) {
+	if value == nil || value == "" {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if status.Properties == nil {
+		status.Properties = make(map[string]json.RawMessage)
+	}
+	status.Properties[key] = raw
+}
+
+var camelBoundary = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+
+// SnakeCase converts "macAddress" to "mac_address" (populate_node.sh's to_snake).
+func SnakeCase(s string) string {
+	return strings.ToLower(camelBoundary.ReplaceAllString(s, "${1}_${2}"))
+}
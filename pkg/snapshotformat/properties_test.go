@@ -0,0 +1,32 @@
+package snapshotformat
+
+import (
+	"testing"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+func TestProperties(t *testing.T) {
+	status := &device.DeviceStatus{} // No Properties map yet
+	SetStringProperty(status, "empty", "")
+	SetProperty(status, "nil", nil)
+	if len(status.Properties) != 0 {
+		t.Errorf("empty values were stored: %v", status.Properties)
+	}
+
+	SetStringProperty(status, "location", "DIMM 1")
+	SetProperty(status, "capacity_mib", 32768)
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"location", "DIMM 1"},
+		{"capacity_mib", ""}, // Not a string
+		{"missing", ""},
+	}
+	for _, tt := range tests {
+		if got := StringProperty(status, tt.key); got != tt.want {
+			t.Errorf("StringProperty(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package snapshotformat
+
+import "time"
+
+// Provenance describes how and when a payload was collected, so the server can
+// judge how fresh and how complete it is. It is optional; payloads from older
+// collectors and other tools carry none.
+type Provenance struct {
+	Collector        string `json:"collector,omitempty"`        // Name of the tool that produced the payload
+	CollectorVersion string `json:"collectorVersion,omitempty"` // Its version
+	Source           string `json:"source,omitempty"`           // Address of the BMC (or host) that was queried
+
+	RedfishVersion string `json:"redfishVersion,omitempty"` // RedfishVersion from the service root
+	ServiceUUID    string `json:"serviceUUID,omitempty"`    // UUID from the service root
+
+	StartedAt   time.Time `json:"startedAt"`   // When collection began
+	CompletedAt time.Time `json:"completedAt"` // When collection finished
+
+	// Warnings lists endpoints that could not be read. A payload with warnings
+	// is missing whatever those endpoints would have contributed.
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// Warning is a problem reading one endpoint during collection.
+type Warning struct {
+	URI     string `json:"uri,omitempty"`
+	Message string `json:"message"`
+}
+
+// Complete reports whether collection finished without warnings.
+func (p *Provenance) Complete() bool {
+	return p != nil && len(p.Warnings) == 0
+}
@@ -0,0 +1,44 @@
+package snapshotformat
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseProvenance(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		source   string
+		complete bool
+	}{
+		{
+			name:     "complete",
+			raw:      `{"format": "redfish-collector/v1", "provenance": {"source": "10.0.0.1", "startedAt": "2025-01-01T12:00:00Z", "completedAt": "2025-01-01T12:00:05Z"}, "data": []}`,
+			source:   "10.0.0.1",
+			complete: true,
+		},
+		{
+			name:   "with warnings",
+			raw:    `{"format": "redfish-collector/v1", "provenance": {"source": "10.0.0.1", "warnings": [{"uri": "/Managers/BMC", "message": "503"}]}, "data": []}`,
+			source: "10.0.0.1",
+		},
+		{
+			name: "none",
+			raw:  `[]`,
+		},
+	}
+	for _, tt := range tests {
+		env, err := Parse(json.RawMessage(tt.raw))
+		if err != nil {
+			t.Fatalf("%s: Parse: %v", tt.name, err)
+		}
+		var source string
+		if env.Provenance != nil {
+			source = env.Provenance.Source
+		}
+		if source != tt.source || env.Provenance.Complete() != tt.complete {
+			t.Errorf("%s: provenance = %+v, want source %q, complete %v", tt.name, env.Provenance, tt.source, tt.complete)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+// Copyright © 2025 OpenCHAMI a Series of LF Projects, LLC
+//
+// SPDX-License-Identifier: MIT
+
+package snapshotformat
+
+import (
+	"encoding/json"
+
+	"github.com/user/inventory-api/pkg/resources/device"
+)
+
+// FormatRedfishCollectorV1 is the device list produced by pkg/collector: an
+// array of DeviceStatus with redfish_uri and redfish_parent_uri properties.
+const FormatRedfishCollectorV1 = "redfish-collector/v1"
+
+func init() {
+	Register(FormatRedfishCollectorV1, DecoderFunc(decodeRedfishCollectorV1))
+}
+
+func decodeRedfishCollectorV1(data json.RawMessage) ([]Entry, error) {
+	var statuses []*device.DeviceStatus
+	if err := json.Unmarshal(data, &statuses); err != nil {
+		return nil, err
+	}
+	entries := make([]Entry, 0, len(statuses))
+	for _, status := range statuses {
+		if status == nil {
+			continue
+		}
+		entries = append(entries, Entry{
+			Status:    status,
+			Ref:       StringProperty(status, "redfish_uri"),
+			ParentRef: StringProperty(status, "redfish_parent_uri"),
+		})
+	}
+	return entries, nil
+}